@@ -0,0 +1,297 @@
+// Package assets attributes IP addresses to your own cloud accounts using
+// inventories exported from the cloud CLIs, so a scan can tell "amazon, owned
+// by account 1234" from "amazon, third party" without live API access.
+//
+// Supported exports:
+//
+//	aws ec2 describe-addresses --output json
+//	aws ec2 describe-network-interfaces --output json
+//	gcloud compute addresses list --format=json
+//	az network public-ip list --output json
+package assets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// Asset is a public IP address held by one of your cloud accounts.
+type Asset struct {
+	IP       string `json:"ip"`
+	Cloud    string `json:"cloud"`              // provider name: amazon, googlecloud, microsoft
+	Account  string `json:"account,omitempty"`  // AWS account, GCP project or Azure subscription
+	Resource string `json:"resource,omitempty"` // ENI, allocation, address or public IP name
+	Region   string `json:"region,omitempty"`
+}
+
+// Attribution tells whether a scanned IP belongs to one of your accounts.
+type Attribution struct {
+	Owned bool   `json:"owned"`
+	Asset *Asset `json:"asset,omitempty"`
+}
+
+// Inventory indexes assets by IP address.
+type Inventory struct {
+	byIP map[string]*Asset
+}
+
+// LoadFiles reads and merges several exported inventories. The format of each
+// file is detected from its contents.
+func LoadFiles(paths []string) (*Inventory, error) {
+	inv := &Inventory{byIP: make(map[string]*Asset)}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading assets %s: %w", path, err)
+		}
+		list, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing assets %s: %w", path, err)
+		}
+		inv.Add(list...)
+	}
+	return inv, nil
+}
+
+// Add indexes assets by IP. When an address is already known, the first
+// entry wins but fields it lacks are filled in from the later one, so
+// describe-addresses and describe-network-interfaces complement each other.
+func (inv *Inventory) Add(list ...Asset) {
+	if inv.byIP == nil {
+		inv.byIP = make(map[string]*Asset)
+	}
+	for _, a := range list {
+		ip := normalizeIP(a.IP)
+		if ip == "" {
+			continue
+		}
+		a.IP = ip
+
+		existing, ok := inv.byIP[ip]
+		if !ok {
+			asset := a
+			inv.byIP[ip] = &asset
+			continue
+		}
+		if existing.Account == "" {
+			existing.Account = a.Account
+		}
+		if existing.Resource == "" {
+			existing.Resource = a.Resource
+		}
+		if existing.Region == "" {
+			existing.Region = a.Region
+		}
+	}
+}
+
+// Len returns the number of known addresses.
+func (inv *Inventory) Len() int {
+	return len(inv.byIP)
+}
+
+// Lookup returns the asset holding ip, or nil if it is not one of yours.
+func (inv *Inventory) Lookup(ip string) *Asset {
+	return inv.byIP[normalizeIP(ip)]
+}
+
+// Attribute returns the ownership of ip.
+func (inv *Inventory) Attribute(ip string) Attribution {
+	if a := inv.Lookup(ip); a != nil {
+		return Attribution{Owned: true, Asset: a}
+	}
+	return Attribution{}
+}
+
+// Parse detects the export format and returns the public addresses it lists.
+func Parse(data []byte) ([]Asset, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	if data[0] == '[' {
+		return parseResourceList(data)
+	}
+
+	var doc struct {
+		Addresses         json.RawMessage `json:"Addresses"`
+		NetworkInterfaces json.RawMessage `json:"NetworkInterfaces"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing JSON: %w", err)
+	}
+	switch {
+	case doc.Addresses != nil:
+		return parseAWSAddresses(doc.Addresses)
+	case doc.NetworkInterfaces != nil:
+		return parseAWSNetworkInterfaces(doc.NetworkInterfaces)
+	}
+	return nil, fmt.Errorf("unrecognized export: expected AWS Addresses/NetworkInterfaces or a GCP/Azure address list")
+}
+
+// parseAWSAddresses reads `aws ec2 describe-addresses` output.
+func parseAWSAddresses(data []byte) ([]Asset, error) {
+	var addresses []struct {
+		PublicIP                string `json:"PublicIp"`
+		AllocationID            string `json:"AllocationId"`
+		InstanceID              string `json:"InstanceId"`
+		NetworkInterfaceID      string `json:"NetworkInterfaceId"`
+		NetworkInterfaceOwnerID string `json:"NetworkInterfaceOwnerId"`
+		NetworkBorderGroup      string `json:"NetworkBorderGroup"`
+	}
+	if err := json.Unmarshal(data, &addresses); err != nil {
+		return nil, fmt.Errorf("parsing Addresses: %w", err)
+	}
+
+	var list []Asset
+	for _, a := range addresses {
+		list = append(list, Asset{
+			IP:       a.PublicIP,
+			Cloud:    "amazon",
+			Account:  a.NetworkInterfaceOwnerID,
+			Resource: firstNonEmpty(a.NetworkInterfaceID, a.InstanceID, a.AllocationID),
+			Region:   a.NetworkBorderGroup,
+		})
+	}
+	return list, nil
+}
+
+// parseAWSNetworkInterfaces reads `aws ec2 describe-network-interfaces`
+// output: the primary and secondary public IPv4 addresses and all IPv6
+// addresses of each interface.
+func parseAWSNetworkInterfaces(data []byte) ([]Asset, error) {
+	type association struct {
+		PublicIP string `json:"PublicIp"`
+	}
+	var enis []struct {
+		NetworkInterfaceID string       `json:"NetworkInterfaceId"`
+		OwnerID            string       `json:"OwnerId"`
+		AvailabilityZone   string       `json:"AvailabilityZone"`
+		Association        *association `json:"Association"`
+		PrivateIPAddresses []struct {
+			Association *association `json:"Association"`
+		} `json:"PrivateIpAddresses"`
+		IPv6Addresses []struct {
+			IPv6Address string `json:"Ipv6Address"`
+		} `json:"Ipv6Addresses"`
+	}
+	if err := json.Unmarshal(data, &enis); err != nil {
+		return nil, fmt.Errorf("parsing NetworkInterfaces: %w", err)
+	}
+
+	var list []Asset
+	for _, eni := range enis {
+		asset := Asset{
+			Cloud:    "amazon",
+			Account:  eni.OwnerID,
+			Resource: eni.NetworkInterfaceID,
+			Region:   eni.AvailabilityZone,
+		}
+		var ips []string
+		if eni.Association != nil {
+			ips = append(ips, eni.Association.PublicIP)
+		}
+		for _, p := range eni.PrivateIPAddresses {
+			if p.Association != nil {
+				ips = append(ips, p.Association.PublicIP)
+			}
+		}
+		for _, v6 := range eni.IPv6Addresses {
+			ips = append(ips, v6.IPv6Address)
+		}
+		for _, ip := range ips {
+			asset.IP = ip
+			list = append(list, asset)
+		}
+	}
+	return list, nil
+}
+
+// parseResourceList reads the top-level arrays produced by
+// `gcloud compute addresses list` and `az network public-ip list`.
+func parseResourceList(data []byte) ([]Asset, error) {
+	var items []struct {
+		// GCP
+		Address     string `json:"address"`
+		AddressType string `json:"addressType"`
+		SelfLink    string `json:"selfLink"`
+		Region      string `json:"region"`
+		// Azure
+		ID        string `json:"id"`
+		IPAddress string `json:"ipAddress"`
+		Location  string `json:"location"`
+		// both
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("parsing JSON: %w", err)
+	}
+
+	var list []Asset
+	for _, it := range items {
+		switch {
+		case it.SelfLink != "":
+			if strings.EqualFold(it.AddressType, "INTERNAL") {
+				continue
+			}
+			list = append(list, Asset{
+				IP:       it.Address,
+				Cloud:    "googlecloud",
+				Account:  pathSegmentAfter(it.SelfLink, "projects"),
+				Resource: it.Name,
+				Region:   lastPathSegment(it.Region),
+			})
+		case strings.Contains(strings.ToLower(it.ID), "/subscriptions/"):
+			list = append(list, Asset{
+				IP:       it.IPAddress,
+				Cloud:    "microsoft",
+				Account:  pathSegmentAfter(it.ID, "subscriptions"),
+				Resource: it.Name,
+				Region:   it.Location,
+			})
+		default:
+			return nil, fmt.Errorf("unrecognized list entry %q: expected a GCP address or Azure public IP", it.Name)
+		}
+	}
+	return list, nil
+}
+
+// pathSegmentAfter returns the path segment following key in a resource URL
+// or ID, e.g. the project of ".../projects/my-proj/regions/...".
+func pathSegmentAfter(path, key string) string {
+	segments := strings.Split(path, "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if strings.EqualFold(segments[i], key) {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
+
+func lastPathSegment(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// normalizeIP returns the canonical form of ip, or "" if it is not an address
+// (e.g. a dynamic Azure public IP that has not been allocated yet).
+func normalizeIP(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+	return parsed.String()
+}
@@ -0,0 +1,166 @@
+package assets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const awsAddresses = `{
+  "Addresses": [
+    {"PublicIp": "3.5.140.10", "AllocationId": "eipalloc-1", "NetworkInterfaceId": "eni-0abc", "NetworkInterfaceOwnerId": "123456789012", "NetworkBorderGroup": "eu-central-1"},
+    {"PublicIp": "3.5.140.11", "AllocationId": "eipalloc-2"}
+  ]
+}`
+
+const awsNetworkInterfaces = `{
+  "NetworkInterfaces": [
+    {
+      "NetworkInterfaceId": "eni-0def",
+      "OwnerId": "123456789012",
+      "AvailabilityZone": "eu-central-1a",
+      "Association": {"PublicIp": "3.5.140.20"},
+      "PrivateIpAddresses": [
+        {"PrivateIpAddress": "10.0.0.5", "Association": {"PublicIp": "3.5.140.20"}},
+        {"PrivateIpAddress": "10.0.0.6", "Association": {"PublicIp": "3.5.140.21"}},
+        {"PrivateIpAddress": "10.0.0.7"}
+      ],
+      "Ipv6Addresses": [{"Ipv6Address": "2a05:d014:0:0::1"}]
+    }
+  ]
+}`
+
+const gcpAddresses = `[
+  {"address": "34.107.1.1", "addressType": "EXTERNAL", "name": "web-ip",
+   "region": "https://www.googleapis.com/compute/v1/projects/my-proj/regions/europe-west3",
+   "selfLink": "https://www.googleapis.com/compute/v1/projects/my-proj/regions/europe-west3/addresses/web-ip"},
+  {"address": "10.1.0.2", "addressType": "INTERNAL", "name": "internal",
+   "selfLink": "https://www.googleapis.com/compute/v1/projects/my-proj/regions/europe-west3/addresses/internal"}
+]`
+
+const azurePublicIPs = `[
+  {"id": "/subscriptions/0000-1111/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/gw-ip",
+   "ipAddress": "20.50.1.1", "location": "westeurope", "name": "gw-ip"},
+  {"id": "/subscriptions/0000-1111/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/dynamic",
+   "name": "dynamic"}
+]`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Asset
+	}{
+		{
+			name:  "aws describe-addresses",
+			input: awsAddresses,
+			want: []Asset{
+				{IP: "3.5.140.10", Cloud: "amazon", Account: "123456789012", Resource: "eni-0abc", Region: "eu-central-1"},
+				{IP: "3.5.140.11", Cloud: "amazon", Resource: "eipalloc-2"},
+			},
+		},
+		{
+			name:  "aws describe-network-interfaces",
+			input: awsNetworkInterfaces,
+			want: []Asset{
+				{IP: "3.5.140.20", Cloud: "amazon", Account: "123456789012", Resource: "eni-0def", Region: "eu-central-1a"},
+				{IP: "3.5.140.20", Cloud: "amazon", Account: "123456789012", Resource: "eni-0def", Region: "eu-central-1a"},
+				{IP: "3.5.140.21", Cloud: "amazon", Account: "123456789012", Resource: "eni-0def", Region: "eu-central-1a"},
+				{IP: "2a05:d014:0:0::1", Cloud: "amazon", Account: "123456789012", Resource: "eni-0def", Region: "eu-central-1a"},
+			},
+		},
+		{
+			name:  "gcp addresses list skips internal",
+			input: gcpAddresses,
+			want: []Asset{
+				{IP: "34.107.1.1", Cloud: "googlecloud", Account: "my-proj", Resource: "web-ip", Region: "europe-west3"},
+			},
+		},
+		{
+			name:  "azure public-ip list",
+			input: azurePublicIPs,
+			want: []Asset{
+				{IP: "20.50.1.1", Cloud: "microsoft", Account: "0000-1111", Resource: "gw-ip", Region: "westeurope"},
+				{IP: "", Cloud: "microsoft", Account: "0000-1111", Resource: "dynamic"},
+			},
+		},
+		{
+			name:  "empty file",
+			input: "  \n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse([]byte(tc.input))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		errContains string
+	}{
+		{"invalid json", "{broken", "parsing JSON"},
+		{"unknown object", `{"Reservations": []}`, "unrecognized export"},
+		{"unknown list entry", `[{"name": "x"}]`, "unrecognized list entry"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.input))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errContains)
+		})
+	}
+}
+
+func TestLoadFiles(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for name, content := range map[string]string{
+		"addresses.json": awsAddresses,
+		"enis.json":      awsNetworkInterfaces,
+		"gcp.json":       gcpAddresses,
+		"azure.json":     azurePublicIPs,
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		paths = append(paths, path)
+	}
+
+	inv, err := LoadFiles(paths)
+	require.NoError(t, err)
+	assert.Equal(t, 7, inv.Len(), "duplicates and unallocated addresses are dropped")
+
+	owner := inv.Attribute("3.5.140.21")
+	assert.True(t, owner.Owned)
+	assert.Equal(t, "eni-0def", owner.Asset.Resource)
+
+	assert.Equal(t, "my-proj", inv.Lookup("34.107.1.1").Account)
+	assert.NotNil(t, inv.Lookup("2a05:d014::1"), "IPv6 lookups use the canonical form")
+	assert.Equal(t, Attribution{}, inv.Attribute("3.5.140.99"))
+
+	_, err = LoadFiles([]string{filepath.Join(dir, "missing.json")})
+	assert.Error(t, err)
+}
+
+func TestInventoryAdd_FillsMissingFields(t *testing.T) {
+	inv := &Inventory{}
+	inv.Add(
+		Asset{IP: "3.5.140.11", Cloud: "amazon", Resource: "eipalloc-2"},
+		Asset{IP: "3.5.140.11", Cloud: "amazon", Account: "123456789012", Resource: "eni-0xyz", Region: "eu-central-1a"},
+	)
+
+	a := inv.Lookup("3.5.140.11")
+	require.NotNil(t, a)
+	assert.Equal(t, "eipalloc-2", a.Resource, "first entry wins")
+	assert.Equal(t, "123456789012", a.Account)
+	assert.Equal(t, "eu-central-1a", a.Region)
+}
@@ -0,0 +1,224 @@
+// Package cidr implements set arithmetic on IP prefixes: collapsing
+// overlapping and adjacent prefixes, subtraction, intersection and address
+// counting. IPv4 and IPv6 prefixes may be mixed freely; the two families never
+// merge. Every function returns the minimal list of prefixes covering exactly
+// the resulting addresses, sorted with IPv4 before IPv6.
+package cidr
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// Parse parses CIDRs, accepting bare addresses as /32 or /128 host prefixes.
+// Host bits are cleared, so "10.0.0.1/8" becomes 10.0.0.0/8.
+func Parse(cidrs []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(cidrs))
+	for _, s := range cidrs {
+		p, err := ParsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// ParsePrefix parses a single CIDR or bare address; see Parse.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if addr, err := netip.ParseAddr(s); err == nil {
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid cidr %q", s)
+	}
+	return p.Masked(), nil
+}
+
+// Strings formats prefixes as CIDR strings. An empty list gives nil.
+func Strings(prefixes []netip.Prefix) []string {
+	if len(prefixes) == 0 {
+		return nil
+	}
+	out := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		out[i] = p.String()
+	}
+	return out
+}
+
+// Aggregate collapses overlapping, duplicate and adjacent prefixes.
+func Aggregate(prefixes []netip.Prefix) []netip.Prefix {
+	return toPrefixes(toRanges(prefixes))
+}
+
+// Union returns the addresses in a or b.
+func Union(a, b []netip.Prefix) []netip.Prefix {
+	return Aggregate(append(slices.Clone(a), b...))
+}
+
+// Intersect returns the addresses in both a and b.
+func Intersect(a, b []netip.Prefix) []netip.Prefix {
+	ra, rb := toRanges(a), toRanges(b)
+	var out []addrRange
+	for i, j := 0, 0; i < len(ra) && j < len(rb); {
+		from, to := maxAddr(ra[i].from, rb[j].from), minAddr(ra[i].to, rb[j].to)
+		if from.Compare(to) <= 0 {
+			out = append(out, addrRange{from, to})
+		}
+		if ra[i].to.Compare(rb[j].to) < 0 {
+			i++
+		} else {
+			j++
+		}
+	}
+	return toPrefixes(out)
+}
+
+// Subtract returns the addresses in a but not in b.
+func Subtract(a, b []netip.Prefix) []netip.Prefix {
+	ra, rb := toRanges(a), toRanges(b)
+	var out []addrRange
+	j := 0
+	for _, r := range ra {
+		for j < len(rb) && rb[j].to.Compare(r.from) < 0 {
+			j++
+		}
+		cur := r
+		for k := j; k < len(rb) && rb[k].from.Compare(cur.to) <= 0; k++ {
+			if rb[k].from.Compare(cur.from) > 0 {
+				out = append(out, addrRange{cur.from, rb[k].from.Prev()})
+			}
+			if rb[k].to.Compare(cur.to) >= 0 {
+				cur.from = netip.Addr{}
+				break
+			}
+			cur.from = rb[k].to.Next()
+		}
+		if cur.from.IsValid() {
+			out = append(out, cur)
+		}
+	}
+	return toPrefixes(out)
+}
+
+// Contains reports whether any of the prefixes contains addr.
+func Contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns the number of distinct IPv4 and IPv6 addresses covered by the
+// prefixes. Overlapping prefixes are counted once. IPv6 counts exceed 64 bits,
+// hence big.Int.
+func Count(prefixes []netip.Prefix) (ipv4, ipv6 *big.Int) {
+	ipv4, ipv6 = new(big.Int), new(big.Int)
+	for _, p := range Aggregate(prefixes) {
+		n := new(big.Int).Lsh(big.NewInt(1), uint(p.Addr().BitLen()-p.Bits()))
+		if p.Addr().Is4() {
+			ipv4.Add(ipv4, n)
+		} else {
+			ipv6.Add(ipv6, n)
+		}
+	}
+	return ipv4, ipv6
+}
+
+// Last returns the highest address of a prefix.
+func Last(p netip.Prefix) netip.Addr {
+	b := p.Masked().Addr().AsSlice()
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// addrRange is an inclusive span of addresses of one family.
+type addrRange struct {
+	from, to netip.Addr
+}
+
+// toRanges converts prefixes into sorted, merged address ranges. Invalid
+// prefixes are skipped.
+func toRanges(prefixes []netip.Prefix) []addrRange {
+	rs := make([]addrRange, 0, len(prefixes))
+	for _, p := range prefixes {
+		if !p.IsValid() {
+			continue
+		}
+		p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()).Masked()
+		if !p.IsValid() {
+			continue // a v4-mapped prefix shorter than ::ffff:0:0/96
+		}
+		rs = append(rs, addrRange{p.Addr(), Last(p)})
+	}
+	slices.SortFunc(rs, func(a, b addrRange) int { return a.from.Compare(b.from) })
+
+	var out []addrRange
+	for _, r := range rs {
+		if n := len(out); n > 0 {
+			last := &out[n-1]
+			// Next is invalid past the end of a family, so v4 and v6 never merge.
+			if r.from.Compare(last.to) <= 0 || r.from == last.to.Next() {
+				if r.to.Compare(last.to) > 0 {
+					last.to = r.to
+				}
+				continue
+			}
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// toPrefixes splits merged ranges into the minimal list of prefixes covering
+// exactly the same addresses.
+func toPrefixes(rs []addrRange) []netip.Prefix {
+	var out []netip.Prefix
+	for _, r := range rs {
+		from := r.from
+		for {
+			// The shortest prefix that starts at from and ends within the range.
+			var p netip.Prefix
+			for bits := 0; bits <= from.BitLen(); bits++ {
+				p = netip.PrefixFrom(from, bits)
+				if p.Masked().Addr() == from && Last(p).Compare(r.to) <= 0 {
+					break
+				}
+			}
+			out = append(out, p)
+			last := Last(p)
+			if last.Compare(r.to) >= 0 {
+				break
+			}
+			from = last.Next()
+		}
+	}
+	return out
+}
+
+func minAddr(a, b netip.Addr) netip.Addr {
+	if a.Compare(b) < 0 {
+		return a
+	}
+	return b
+}
+
+func maxAddr(a, b netip.Addr) netip.Addr {
+	if a.Compare(b) > 0 {
+		return a
+	}
+	return b
+}
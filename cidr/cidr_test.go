@@ -0,0 +1,131 @@
+package cidr
+
+import (
+	"math/big"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, cidrs ...string) []netip.Prefix {
+	t.Helper()
+	prefixes, err := Parse(cidrs)
+	require.NoError(t, err)
+	return prefixes
+}
+
+func TestParse(t *testing.T) {
+	prefixes, err := Parse([]string{"10.0.0.1/8", " 192.0.2.7 ", "2001:db8::1", "::ffff:192.0.2.1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.0.2.7/32", "2001:db8::1/128", "192.0.2.1/32"}, Strings(prefixes))
+
+	_, err = Parse([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid cidr "10.0.0.0/33"`)
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{"empty", nil, nil},
+		{"duplicates", []string{"10.0.0.0/24", "10.0.0.0/24"}, []string{"10.0.0.0/24"}},
+		{"contained", []string{"10.0.0.0/16", "10.0.5.0/24"}, []string{"10.0.0.0/16"}},
+		{"adjacent halves", []string{"10.0.1.0/24", "10.0.0.0/24"}, []string{"10.0.0.0/23"}},
+		{"adjacent but unaligned", []string{"10.0.1.0/24", "10.0.2.0/24"}, []string{"10.0.1.0/24", "10.0.2.0/24"}},
+		{"three into two", []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24"}, []string{"10.0.0.0/23", "10.0.2.0/24"}},
+		{"sorted v4 before v6", []string{"2001:db8::/33", "192.0.2.0/24", "2001:db8:8000::/33"}, []string{"192.0.2.0/24", "2001:db8::/32"}},
+		{"families never merge", []string{"255.255.255.255/32", "::/128"}, []string{"255.255.255.255/32", "::/128"}},
+		{"whole address space", []string{"0.0.0.0/1", "128.0.0.0/1"}, []string{"0.0.0.0/0"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Strings(Aggregate(mustParse(t, tc.in...))))
+		})
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []string
+	}{
+		{"nothing to remove", []string{"10.0.0.0/24"}, []string{"10.1.0.0/24"}, []string{"10.0.0.0/24"}},
+		{"everything removed", []string{"10.0.0.0/24"}, []string{"10.0.0.0/16"}, nil},
+		{"hole in the middle", []string{"10.0.0.0/22"}, []string{"10.0.1.0/24"}, []string{"10.0.0.0/24", "10.0.2.0/23"}},
+		{"several holes", []string{"10.0.0.0/24"}, []string{"10.0.0.0/26", "10.0.0.128/26"}, []string{"10.0.0.64/26", "10.0.0.192/26"}},
+		{"single address", []string{"10.0.0.0/30"}, []string{"10.0.0.2/32"}, []string{"10.0.0.0/31", "10.0.0.3/32"}},
+		{"ipv6", []string{"2001:db8::/32"}, []string{"2001:db8:8000::/33"}, []string{"2001:db8::/33"}},
+		{"families are independent", []string{"10.0.0.0/24", "2001:db8::/32"}, []string{"::/0"}, []string{"10.0.0.0/24"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Strings(Subtract(mustParse(t, tc.a...), mustParse(t, tc.b...)))
+			if tc.want == nil {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []string
+	}{
+		{"disjoint", []string{"10.0.0.0/24"}, []string{"10.1.0.0/24"}, nil},
+		{"contained", []string{"10.0.0.0/16"}, []string{"10.0.3.0/24", "10.2.0.0/24"}, []string{"10.0.3.0/24"}},
+		{"partial overlap", []string{"10.0.0.0/23", "10.0.4.0/24"}, []string{"10.0.1.0/24", "10.0.4.0/22"}, []string{"10.0.1.0/24", "10.0.4.0/24"}},
+		{"mixed families", []string{"10.0.0.0/8", "2001:db8::/32"}, []string{"2001:db8:1::/48"}, []string{"2001:db8:1::/48"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Strings(Intersect(mustParse(t, tc.a...), mustParse(t, tc.b...)))
+			if tc.want == nil {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestUnion(t *testing.T) {
+	got := Union(mustParse(t, "10.0.0.0/25"), mustParse(t, "10.0.0.128/25", "2001:db8::/32"))
+	assert.Equal(t, []string{"10.0.0.0/24", "2001:db8::/32"}, Strings(got))
+}
+
+func TestContains(t *testing.T) {
+	prefixes := mustParse(t, "10.0.0.0/8", "2001:db8::/32")
+	assert.True(t, Contains(prefixes, netip.MustParseAddr("10.1.2.3")))
+	assert.True(t, Contains(prefixes, netip.MustParseAddr("::ffff:10.1.2.3")))
+	assert.True(t, Contains(prefixes, netip.MustParseAddr("2001:db8::1")))
+	assert.False(t, Contains(prefixes, netip.MustParseAddr("11.0.0.1")))
+}
+
+func TestCount(t *testing.T) {
+	ipv4, ipv6 := Count(mustParse(t, "10.0.0.0/24", "10.0.0.0/25", "192.0.2.1", "2001:db8::/32"))
+	assert.Equal(t, int64(257), ipv4.Int64(), "overlaps are counted once")
+	assert.Equal(t, new(big.Int).Lsh(big.NewInt(1), 96), ipv6)
+
+	ipv4, ipv6 = Count(nil)
+	assert.Zero(t, ipv4.Sign())
+	assert.Zero(t, ipv6.Sign())
+}
+
+func TestLast(t *testing.T) {
+	assert.Equal(t, "10.0.0.255", Last(netip.MustParsePrefix("10.0.0.0/24")).String())
+	assert.Equal(t, "10.0.0.255", Last(netip.MustParsePrefix("10.0.0.7/24")).String())
+	assert.Equal(t, "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", Last(netip.MustParsePrefix("2001:db8::/32")).String())
+}
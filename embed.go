@@ -8,7 +8,8 @@ import (
 
 // embeddedData holds a build-time snapshot of every provider's IP ranges so the
 // tool works out of the box after `go install`, without a prior `update` run.
-// Fresh data written to the data directory by `update` always takes precedence.
+// Fresh data written to the data directory by `update` always takes precedence,
+// and providers with a refresh interval (tor) are never served from here.
 //
 //go:embed */ipranges.json
 var embeddedData embed.FS
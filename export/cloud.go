@@ -0,0 +1,449 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/BenjiTrapp/ip-to-cloudprovider/cidr"
+)
+
+// Per-object limits of the cloud targets. Lists that exceed them are split
+// into several numbered objects; Options.MaxEntries overrides the default,
+// e.g. after a quota increase.
+const (
+	awsSGRules       = 60    // inbound rules per security group and family (default quota)
+	awsWAFAddresses  = 10000 // addresses per WAF IPSet
+	azureNSGPrefixes = 4000  // address prefixes per network security group
+	gcpSourceRanges  = 5000  // source ranges per firewall rule
+	k8sPeers         = 1000  // ipBlocks or nets per manifest, well below the etcd object size limit
+
+	maxDNSLabel = 63 // GCP resource names and Kubernetes label values
+)
+
+func init() {
+	Register(Format{Name: "aws-sg", Description: "AWS security groups (JSON), 60 rules per family each", Render: renderAWSSecurityGroups})
+	Register(Format{Name: "aws-waf", Description: "AWS WAF IPSets (JSON), 10000 addresses each", Render: renderAWSWAFIPSets})
+	Register(Format{Name: "azure-nsg", Description: "Azure network security groups (JSON), 4000 prefixes each", Render: renderAzureNSGs})
+	Register(Format{Name: "gcp-firewall", Description: "GCP firewall rules (YAML), 5000 ranges each", Render: renderGCPFirewall})
+	Register(Format{Name: "terraform", Description: "Terraform locals block with one list per family", Render: renderTerraformLocals})
+	Register(Format{Name: "tfvars", Description: "Terraform .tfvars with one list per family", Render: renderTFVars})
+	Register(Format{Name: "k8s-networkpolicy", Description: "Kubernetes NetworkPolicy ingress ipBlocks (YAML), 1000 each", Render: renderNetworkPolicies})
+	Register(Format{Name: "calico", Description: "Calico GlobalNetworkSets (YAML), 1000 nets each", Render: renderCalicoNetworkSets})
+}
+
+// limit returns the per-object limit, honouring Options.MaxEntries.
+func limit(opts Options, def int) int {
+	if opts.MaxEntries > 0 {
+		return opts.MaxEntries
+	}
+	return def
+}
+
+// chunk splits prefixes into consecutive slices of at most size entries.
+func chunk(prefixes []netip.Prefix, size int) [][]netip.Prefix {
+	var out [][]netip.Prefix
+	for len(prefixes) > size {
+		out = append(out, prefixes[:size])
+		prefixes = prefixes[size:]
+	}
+	if len(prefixes) > 0 {
+		out = append(out, prefixes)
+	}
+	return out
+}
+
+// allowOnly rejects deny lists for targets that can only permit traffic.
+func allowOnly(format string, opts Options) error {
+	if opts.Action == ActionDeny {
+		return fmt.Errorf("%s can only allow traffic; use --action allow", format)
+	}
+	return nil
+}
+
+var invalidDNSChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// dnsName turns a list name into an RFC 1123 label as Kubernetes and GCP
+// require: lowercase letters, digits and hyphens, starting with a letter.
+func dnsName(name string) string {
+	n := strings.Trim(invalidDNSChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if n == "" || n[0] < 'a' || n[0] > 'z' {
+		n = "list-" + n
+	}
+	return n
+}
+
+// part describes one of several objects generated for a list. Unlike header
+// it is short enough for the description limits of cloud APIs (140
+// characters for Azure rules).
+func part(l List, i, n int) string {
+	return fmt.Sprintf("%s, part %d of %d, generated by ip-to-cloudprovider", l.Name, i+1, n)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeYAML writes the header comment followed by one document per object.
+func writeYAML[T any](w io.Writer, l List, docs []T) error {
+	if _, err := fmt.Fprintf(w, "# %s\n", header(l)); err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// AWS
+
+type awsSecurityGroup struct {
+	GroupName     string             `json:"GroupName"`
+	Description   string             `json:"Description"`
+	IPPermissions []awsIPPermissions `json:"IpPermissions"`
+}
+
+type awsIPPermissions struct {
+	IPProtocol string         `json:"IpProtocol"`
+	IPRanges   []awsIPRange   `json:"IpRanges,omitempty"`
+	IPv6Ranges []awsIPv6Range `json:"Ipv6Ranges,omitempty"`
+}
+
+type awsIPRange struct {
+	CidrIP      string `json:"CidrIp"`
+	Description string `json:"Description"`
+}
+
+type awsIPv6Range struct {
+	CidrIPv6    string `json:"CidrIpv6"`
+	Description string `json:"Description"`
+}
+
+// renderAWSSecurityGroups writes a JSON array of security groups whose
+// IpPermissions allow all protocols from the listed prefixes. Each group
+// holds up to the rule quota per family, so the first group takes the first
+// IPv4 and the first IPv6 chunk, and so on.
+func renderAWSSecurityGroups(w io.Writer, l List, opts Options) error {
+	if err := allowOnly("aws-sg", opts); err != nil {
+		return err
+	}
+	n := limit(opts, awsSGRules)
+	v4, v6 := chunk(l.IPv4, n), chunk(l.IPv6, n)
+	groups := make([]awsSecurityGroup, max(len(v4), len(v6)))
+	for i := range groups {
+		perm := awsIPPermissions{IPProtocol: "-1"}
+		if i < len(v4) {
+			for _, p := range v4[i] {
+				perm.IPRanges = append(perm.IPRanges, awsIPRange{p.String(), l.Name})
+			}
+		}
+		if i < len(v6) {
+			for _, p := range v6[i] {
+				perm.IPv6Ranges = append(perm.IPv6Ranges, awsIPv6Range{p.String(), l.Name})
+			}
+		}
+		groups[i] = awsSecurityGroup{
+			GroupName:     fmt.Sprintf("%s-%d", l.Name, i+1),
+			Description:   part(l, i, len(groups)),
+			IPPermissions: []awsIPPermissions{perm},
+		}
+	}
+	return writeJSON(w, groups)
+}
+
+type awsIPSet struct {
+	Name             string   `json:"Name"`
+	Scope            string   `json:"Scope"`
+	IPAddressVersion string   `json:"IPAddressVersion"`
+	Description      string   `json:"Description"`
+	Addresses        []string `json:"Addresses"`
+}
+
+// renderAWSWAFIPSets writes a JSON array of WAFv2 IPSets, one family each.
+// Whether the sets allow or block is decided by the web ACL rule that
+// references them, so both actions produce the same sets.
+func renderAWSWAFIPSets(w io.Writer, l List, opts Options) error {
+	n := limit(opts, awsWAFAddresses)
+	var sets []awsIPSet
+	for _, f := range []struct {
+		prefixes []netip.Prefix
+		version  string
+		suffix   string
+	}{{l.IPv4, "IPV4", "v4"}, {l.IPv6, "IPV6", "v6"}} {
+		chunks := chunk(f.prefixes, n)
+		for i, c := range chunks {
+			sets = append(sets, awsIPSet{
+				Name:             fmt.Sprintf("%s-%s-%d", identifier(l.Name), f.suffix, i+1),
+				Scope:            "REGIONAL",
+				IPAddressVersion: f.version,
+				Description:      part(l, i, len(chunks)),
+				Addresses:        cidr.Strings(c),
+			})
+		}
+	}
+	return writeJSON(w, sets)
+}
+
+// Azure
+
+type azureNSG struct {
+	Name       string             `json:"name"`
+	Properties azureNSGProperties `json:"properties"`
+}
+
+type azureNSGProperties struct {
+	SecurityRules []azureSecurityRule `json:"securityRules"`
+}
+
+type azureSecurityRule struct {
+	Name       string                  `json:"name"`
+	Properties azureSecurityRuleFields `json:"properties"`
+}
+
+type azureSecurityRuleFields struct {
+	Description              string   `json:"description"`
+	Priority                 int      `json:"priority"`
+	Direction                string   `json:"direction"`
+	Access                   string   `json:"access"`
+	Protocol                 string   `json:"protocol"`
+	SourceAddressPrefixes    []string `json:"sourceAddressPrefixes"`
+	SourcePortRange          string   `json:"sourcePortRange"`
+	DestinationAddressPrefix string   `json:"destinationAddressPrefix"`
+	DestinationPortRange     string   `json:"destinationPortRange"`
+}
+
+// renderAzureNSGs writes a JSON array of network security groups in ARM
+// resource shape. Each NSG holds up to the prefix limit, with one inbound
+// rule per address family since a rule cannot mix them.
+func renderAzureNSGs(w io.Writer, l List, opts Options) error {
+	access := verdict(opts, "Allow", "Deny")
+	chunks := chunk(l.Prefixes(), limit(opts, azureNSGPrefixes))
+	nsgs := make([]azureNSG, len(chunks))
+	for i, c := range chunks {
+		nsg := azureNSG{Name: fmt.Sprintf("%s-%d", l.Name, i+1)}
+		split := NewList(l.Name, nil, c)
+		priority := 100
+		for _, f := range []struct {
+			prefixes []netip.Prefix
+			suffix   string
+		}{{split.IPv4, "v4"}, {split.IPv6, "v6"}} {
+			if len(f.prefixes) == 0 {
+				continue
+			}
+			nsg.Properties.SecurityRules = append(nsg.Properties.SecurityRules, azureSecurityRule{
+				Name: fmt.Sprintf("%s-%s", l.Name, f.suffix),
+				Properties: azureSecurityRuleFields{
+					Description:              part(l, i, len(chunks)),
+					Priority:                 priority,
+					Direction:                "Inbound",
+					Access:                   access,
+					Protocol:                 "*",
+					SourceAddressPrefixes:    cidr.Strings(f.prefixes),
+					SourcePortRange:          "*",
+					DestinationAddressPrefix: "*",
+					DestinationPortRange:     "*",
+				},
+			})
+			priority++
+		}
+		nsgs[i] = nsg
+	}
+	return writeJSON(w, nsgs)
+}
+
+// GCP
+
+type gcpFirewallRule struct {
+	Name         string        `yaml:"name"`
+	Description  string        `yaml:"description"`
+	Network      string        `yaml:"network"`
+	Direction    string        `yaml:"direction"`
+	Priority     int           `yaml:"priority"`
+	Allowed      []gcpProtocol `yaml:"allowed,omitempty"`
+	Denied       []gcpProtocol `yaml:"denied,omitempty"`
+	SourceRanges []string      `yaml:"sourceRanges"`
+}
+
+type gcpProtocol struct {
+	IPProtocol string `yaml:"IPProtocol"`
+}
+
+// renderGCPFirewall writes firewall rules in the Compute API shape, one YAML
+// document each. A rule cannot mix IPv4 and IPv6 ranges, so each family gets
+// its own rules.
+func renderGCPFirewall(w io.Writer, l List, opts Options) error {
+	n := limit(opts, gcpSourceRanges)
+	name := dnsName(l.Name)
+	var rules []gcpFirewallRule
+	for _, f := range []struct {
+		prefixes []netip.Prefix
+		suffix   string
+	}{{l.IPv4, "v4"}, {l.IPv6, "v6"}} {
+		chunks := chunk(f.prefixes, n)
+		for i, c := range chunks {
+			ruleName := fmt.Sprintf("%s-%s-%d", name, f.suffix, i+1)
+			if err := checkLength("GCP firewall rule", ruleName, maxDNSLabel); err != nil {
+				return err
+			}
+			rule := gcpFirewallRule{
+				Name:         ruleName,
+				Description:  fmt.Sprintf("%s (%d of %d)", header(l), i+1, len(chunks)),
+				Network:      "global/networks/default",
+				Direction:    "INGRESS",
+				Priority:     1000,
+				SourceRanges: cidr.Strings(c),
+			}
+			if opts.Action == ActionDeny {
+				rule.Denied = []gcpProtocol{{"all"}}
+			} else {
+				rule.Allowed = []gcpProtocol{{"all"}}
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return writeYAML(w, l, rules)
+}
+
+// Terraform
+
+// renderTerraformLocals writes a locals block with <name>_ipv4 and
+// <name>_ipv6 lists. Terraform has no size limit of its own; split the lists
+// with chunklist() where the consuming resource has one.
+func renderTerraformLocals(w io.Writer, l List, opts Options) error {
+	ew := &errWriter{w: w}
+	ew.printf("# %s\nlocals {\n", header(l))
+	writeHCLLists(ew, l, "  ")
+	ew.printf("}\n")
+	return ew.err
+}
+
+// renderTFVars writes <name>_ipv4 and <name>_ipv6 variable assignments.
+func renderTFVars(w io.Writer, l List, opts Options) error {
+	ew := &errWriter{w: w}
+	ew.printf("# %s\n", header(l))
+	writeHCLLists(ew, l, "")
+	return ew.err
+}
+
+func writeHCLLists(ew *errWriter, l List, indent string) {
+	id := identifier(l.Name)
+	for _, f := range []struct {
+		prefixes []netip.Prefix
+		suffix   string
+	}{{l.IPv4, "ipv4"}, {l.IPv6, "ipv6"}} {
+		if len(f.prefixes) == 0 {
+			ew.printf("%s%s_%s = []\n", indent, id, f.suffix)
+			continue
+		}
+		ew.printf("%s%s_%s = [\n", indent, id, f.suffix)
+		for _, p := range f.prefixes {
+			ew.printf("%s  %q,\n", indent, p.String())
+		}
+		ew.printf("%s]\n", indent)
+	}
+}
+
+// Kubernetes
+
+type k8sMetadata struct {
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+type networkPolicy struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Spec       networkPolicySpec `yaml:"spec"`
+}
+
+type networkPolicySpec struct {
+	PodSelector struct{}            `yaml:"podSelector"`
+	PolicyTypes []string            `yaml:"policyTypes"`
+	Ingress     []networkPolicyRule `yaml:"ingress"`
+}
+
+type networkPolicyRule struct {
+	From []networkPolicyPeer `yaml:"from"`
+}
+
+type networkPolicyPeer struct {
+	IPBlock struct {
+		CIDR string `yaml:"cidr"`
+	} `yaml:"ipBlock"`
+}
+
+// listLabel marks every object generated for a list, so split objects can
+// be selected (Calico) or pruned (GitOps) together.
+const listLabel = "ip-to-cloudprovider/list"
+
+// renderNetworkPolicies writes NetworkPolicies admitting ingress from the
+// listed prefixes to every pod of the namespace they are applied to. Policies
+// are additive, so splitting a long list across several is equivalent to one.
+func renderNetworkPolicies(w io.Writer, l List, opts Options) error {
+	if err := allowOnly("k8s-networkpolicy", opts); err != nil {
+		return err
+	}
+	name := dnsName(l.Name)
+	if err := checkLength("Kubernetes label", name, maxDNSLabel); err != nil {
+		return err
+	}
+	var policies []networkPolicy
+	for i, c := range chunk(l.Prefixes(), limit(opts, k8sPeers)) {
+		np := networkPolicy{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       "NetworkPolicy",
+			Metadata:   k8sMetadata{Name: fmt.Sprintf("%s-%d", name, i+1), Labels: map[string]string{listLabel: name}},
+		}
+		np.Spec.PolicyTypes = []string{"Ingress"}
+		rule := networkPolicyRule{}
+		for _, p := range c {
+			var peer networkPolicyPeer
+			peer.IPBlock.CIDR = p.String()
+			rule.From = append(rule.From, peer)
+		}
+		np.Spec.Ingress = []networkPolicyRule{rule}
+		policies = append(policies, np)
+	}
+	return writeYAML(w, l, policies)
+}
+
+type calicoNetworkSet struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   k8sMetadata `yaml:"metadata"`
+	Spec       struct {
+		Nets []string `yaml:"nets"`
+	} `yaml:"spec"`
+}
+
+// renderCalicoNetworkSets writes GlobalNetworkSets labelled with the list
+// name; a GlobalNetworkPolicy selecting that label allows or denies them.
+func renderCalicoNetworkSets(w io.Writer, l List, opts Options) error {
+	name := dnsName(l.Name)
+	if err := checkLength("Kubernetes label", name, maxDNSLabel); err != nil {
+		return err
+	}
+	var sets []calicoNetworkSet
+	for i, c := range chunk(l.Prefixes(), limit(opts, k8sPeers)) {
+		set := calicoNetworkSet{
+			APIVersion: "projectcalico.org/v3",
+			Kind:       "GlobalNetworkSet",
+			Metadata:   k8sMetadata{Name: fmt.Sprintf("%s-%d", name, i+1), Labels: map[string]string{listLabel: name}},
+		}
+		set.Spec.Nets = cidr.Strings(c)
+		sets = append(sets, set)
+	}
+	return writeYAML(w, l, sets)
+}
@@ -0,0 +1,171 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// cloudList has 3 IPv4 and 2 IPv6 prefixes.
+func cloudList() List {
+	return testList("gh-hooks", "140.82.112.0/20", "143.55.64.0/20", "192.30.252.0/22", "2606:50c0::/32", "2a0a:a440::/29")
+}
+
+func renderOpts(t *testing.T, format string, l List, opts Options) string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, format, l, opts))
+	return buf.String()
+}
+
+// yamlDocs decodes every document of a multi-document YAML stream.
+func yamlDocs(t *testing.T, out string) []map[string]any {
+	t.Helper()
+	dec := yaml.NewDecoder(bytes.NewBufferString(out))
+	var docs []map[string]any
+	for {
+		var doc map[string]any
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs
+		}
+		require.NoError(t, err)
+		docs = append(docs, doc)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	l := cloudList()
+	assert.Empty(t, chunk(nil, 2))
+	assert.Len(t, chunk(l.Prefixes(), 5), 1)
+	chunks := chunk(l.Prefixes(), 2)
+	require.Len(t, chunks, 3)
+	assert.Len(t, chunks[2], 1)
+}
+
+func TestAWSSecurityGroups(t *testing.T) {
+	var groups []awsSecurityGroup
+	require.NoError(t, json.Unmarshal([]byte(renderOpts(t, "aws-sg", cloudList(), Options{MaxEntries: 2})), &groups))
+
+	require.Len(t, groups, 2, "two IPv4 chunks, one IPv6 chunk")
+	assert.Equal(t, "gh-hooks-1", groups[0].GroupName)
+	assert.Equal(t, "gh-hooks, part 1 of 2, generated by ip-to-cloudprovider", groups[0].Description)
+	assert.Len(t, groups[0].IPPermissions[0].IPRanges, 2)
+	assert.Len(t, groups[0].IPPermissions[0].IPv6Ranges, 2)
+	assert.Equal(t, "192.30.252.0/22", groups[1].IPPermissions[0].IPRanges[0].CidrIP)
+	assert.Empty(t, groups[1].IPPermissions[0].IPv6Ranges)
+
+	err := Render(&bytes.Buffer{}, "aws-sg", cloudList(), Options{Action: ActionDeny})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "aws-sg can only allow traffic")
+}
+
+func TestAWSWAFIPSets(t *testing.T) {
+	var sets []awsIPSet
+	require.NoError(t, json.Unmarshal([]byte(renderOpts(t, "aws-waf", cloudList(), Options{Action: ActionDeny})), &sets))
+
+	require.Len(t, sets, 2, "one set per family")
+	assert.Equal(t, "gh_hooks-v4-1", sets[0].Name)
+	assert.Equal(t, "IPV4", sets[0].IPAddressVersion)
+	assert.Equal(t, "REGIONAL", sets[0].Scope)
+	assert.Equal(t, []string{"140.82.112.0/20", "143.55.64.0/20", "192.30.252.0/22"}, sets[0].Addresses)
+	assert.Equal(t, "IPV6", sets[1].IPAddressVersion)
+}
+
+func TestAzureNSGs(t *testing.T) {
+	var nsgs []azureNSG
+	require.NoError(t, json.Unmarshal([]byte(renderOpts(t, "azure-nsg", cloudList(), Options{Action: ActionDeny, MaxEntries: 4})), &nsgs))
+
+	require.Len(t, nsgs, 2)
+	rules := nsgs[1].Properties.SecurityRules
+	require.Len(t, rules, 1, "the second NSG only holds the last IPv6 prefix")
+	assert.Equal(t, "gh-hooks-v6", rules[0].Name)
+	assert.Equal(t, "Deny", rules[0].Properties.Access)
+	assert.Equal(t, []string{"2a0a:a440::/29"}, rules[0].Properties.SourceAddressPrefixes)
+
+	rules = nsgs[0].Properties.SecurityRules
+	require.Len(t, rules, 2, "families never share a rule")
+	assert.Equal(t, 100, rules[0].Properties.Priority)
+	assert.Equal(t, 101, rules[1].Properties.Priority)
+	assert.Equal(t, "Inbound", rules[0].Properties.Direction)
+}
+
+func TestGCPFirewall(t *testing.T) {
+	out := renderOpts(t, "gcp-firewall", cloudList(), Options{Action: ActionDeny})
+	assert.Contains(t, out, "# gh-hooks: 3 IPv4 and 2 IPv6 prefixes")
+
+	docs := yamlDocs(t, out)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "gh-hooks-v4-1", docs[0]["name"])
+	assert.Equal(t, "INGRESS", docs[0]["direction"])
+	assert.Equal(t, []any{map[string]any{"IPProtocol": "all"}}, docs[0]["denied"])
+	assert.NotContains(t, docs[0], "allowed")
+	assert.Equal(t, []any{"2606:50c0::/32", "2a0a:a440::/29"}, docs[1]["sourceRanges"])
+}
+
+func TestTerraform(t *testing.T) {
+	l := testList("gh-hooks", "192.0.2.0/24")
+
+	assert.Equal(t, `# gh-hooks: 1 IPv4 and 0 IPv6 prefixes from githubhooks, generated by ip-to-cloudprovider
+locals {
+  gh_hooks_ipv4 = [
+    "192.0.2.0/24",
+  ]
+  gh_hooks_ipv6 = []
+}
+`, render(t, "terraform", l, ActionAllow))
+
+	assert.Equal(t, `# gh-hooks: 1 IPv4 and 0 IPv6 prefixes from githubhooks, generated by ip-to-cloudprovider
+gh_hooks_ipv4 = [
+  "192.0.2.0/24",
+]
+gh_hooks_ipv6 = []
+`, render(t, "tfvars", l, ActionAllow))
+}
+
+func TestNetworkPolicies(t *testing.T) {
+	docs := yamlDocs(t, renderOpts(t, "k8s-networkpolicy", cloudList(), Options{MaxEntries: 3}))
+	require.Len(t, docs, 2)
+	assert.Equal(t, "NetworkPolicy", docs[0]["kind"])
+
+	meta := docs[1]["metadata"].(map[string]any)
+	assert.Equal(t, "gh-hooks-2", meta["name"])
+	assert.Equal(t, map[string]any{listLabel: "gh-hooks"}, meta["labels"])
+
+	spec := docs[1]["spec"].(map[string]any)
+	assert.Equal(t, map[string]any{}, spec["podSelector"])
+	from := spec["ingress"].([]any)[0].(map[string]any)["from"].([]any)
+	assert.Equal(t, map[string]any{"ipBlock": map[string]any{"cidr": "2606:50c0::/32"}}, from[0])
+
+	err := Render(&bytes.Buffer{}, "k8s-networkpolicy", cloudList(), Options{Action: ActionDeny})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can only allow traffic")
+}
+
+func TestCalicoNetworkSets(t *testing.T) {
+	l := cloudList()
+	l.Name = "GitHub.Hooks"
+	docs := yamlDocs(t, renderOpts(t, "calico", l, Options{}))
+	require.Len(t, docs, 1)
+	assert.Equal(t, "GlobalNetworkSet", docs[0]["kind"])
+	assert.Equal(t, "github-hooks-1", docs[0]["metadata"].(map[string]any)["name"])
+	assert.Len(t, docs[0]["spec"].(map[string]any)["nets"], 5)
+}
+
+func TestDNSName(t *testing.T) {
+	assert.Equal(t, "gh-hooks", dnsName("gh_hooks"))
+	assert.Equal(t, "github-hooks", dnsName("-GitHub.Hooks-"))
+	assert.Equal(t, "list-365", dnsName("365"))
+}
+
+func TestRender_NegativeLimit(t *testing.T) {
+	err := Render(&bytes.Buffer{}, "aws-waf", cloudList(), Options{MaxEntries: -1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid per-object limit")
+}
@@ -0,0 +1,177 @@
+// Package export renders prefix lists in the formats firewalls, proxies and
+// web servers consume, so allow- and blocklists can be generated from provider
+// data instead of being maintained by hand.
+package export
+
+import (
+	"fmt"
+	"io"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BenjiTrapp/ip-to-cloudprovider/provider"
+)
+
+// Actions a rendered list can apply to matching traffic.
+const (
+	ActionAllow = "allow"
+	ActionDeny  = "deny"
+)
+
+// List is a named set of prefixes to render.
+type List struct {
+	Name    string   // identifier for sets, chains and ACLs, e.g. "githubhooks"
+	Sources []string // providers the prefixes come from, for the header comment
+	IPv4    []netip.Prefix
+	IPv6    []netip.Prefix
+
+	// Attrs optionally describes where each prefix comes from. Formats that
+	// carry per-prefix details (see Format.PerPrefix) read it; the others
+	// ignore it.
+	Attrs map[netip.Prefix]Attr
+}
+
+// Attr is the provider and published metadata behind one prefix.
+type Attr struct {
+	Provider    string
+	DisplayName string
+	Category    string
+	Meta        *provider.PrefixMeta // nil when the provider publishes none
+}
+
+// NewList splits prefixes by family, keeping their order.
+func NewList(name string, sources []string, prefixes []netip.Prefix) List {
+	l := List{Name: name, Sources: sources}
+	for _, p := range prefixes {
+		if p.Addr().Is4() {
+			l.IPv4 = append(l.IPv4, p)
+		} else {
+			l.IPv6 = append(l.IPv6, p)
+		}
+	}
+	return l
+}
+
+// Prefixes returns the IPv4 prefixes followed by the IPv6 ones.
+func (l List) Prefixes() []netip.Prefix {
+	return append(append([]netip.Prefix{}, l.IPv4...), l.IPv6...)
+}
+
+// Options control how a list is rendered.
+type Options struct {
+	Action string // ActionAllow or ActionDeny
+
+	// MaxEntries overrides the per-object prefix limit of formats that split
+	// large lists into several objects, e.g. for a raised cloud quota.
+	MaxEntries int
+
+	// BuildTime is recorded by formats that carry one (mmdb). Zero means now.
+	BuildTime time.Time
+}
+
+// RenderFunc writes a list in one format.
+type RenderFunc func(w io.Writer, l List, opts Options) error
+
+// Format is an export target.
+type Format struct {
+	Name        string
+	Description string
+	Render      RenderFunc
+
+	// PerPrefix formats write each prefix's provider and metadata, which
+	// aggregation would merge away.
+	PerPrefix bool
+}
+
+// formats holds the registered export targets.
+var formats []Format
+
+// Register adds an export format.
+func Register(f Format) {
+	formats = append(formats, f)
+}
+
+// Formats returns the registered formats sorted by name.
+func Formats() []Format {
+	out := append([]Format{}, formats...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ByName returns the format with the given name, or nil.
+func ByName(name string) *Format {
+	for i := range formats {
+		if formats[i].Name == name {
+			return &formats[i]
+		}
+	}
+	return nil
+}
+
+// Render writes l in the named format. The action defaults to allow.
+func Render(w io.Writer, format string, l List, opts Options) error {
+	f := ByName(format)
+	if f == nil {
+		return fmt.Errorf("unknown export format %q", format)
+	}
+	switch opts.Action {
+	case "":
+		opts.Action = ActionAllow
+	case ActionAllow, ActionDeny:
+	default:
+		return fmt.Errorf("unknown action %q (use %s or %s)", opts.Action, ActionAllow, ActionDeny)
+	}
+	if l.Name == "" {
+		return fmt.Errorf("list has no name")
+	}
+	if opts.MaxEntries < 0 {
+		return fmt.Errorf("invalid per-object limit %d", opts.MaxEntries)
+	}
+	return f.Render(w, l, opts)
+}
+
+// header returns the comment line describing a list, without the comment
+// marker. It carries no timestamp so repeated exports of the same data are
+// byte-identical.
+func header(l List) string {
+	return fmt.Sprintf("%s: %d IPv4 and %d IPv6 prefixes from %s, generated by ip-to-cloudprovider",
+		l.Name, len(l.IPv4), len(l.IPv6), strings.Join(l.Sources, ", "))
+}
+
+// invalidIdentChars matches characters that are not allowed in identifiers
+// of the stricter formats (nftables, HAProxy, cloud resource names).
+var invalidIdentChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// identifier turns a list name into a conservative identifier: letters,
+// digits and underscores, not starting with a digit.
+func identifier(name string) string {
+	id := invalidIdentChars.ReplaceAllString(name, "_")
+	if id == "" || (id[0] >= '0' && id[0] <= '9') {
+		id = "_" + id
+	}
+	return id
+}
+
+// checkLength fails when a derived name exceeds a format's limit.
+func checkLength(kind, name string, max int) error {
+	if len(name) > max {
+		return fmt.Errorf("%s name %q is longer than %d characters; choose a shorter --name", kind, name, max)
+	}
+	return nil
+}
+
+// errWriter remembers the first write error so renderers can print freely
+// and check once at the end.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...any) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
@@ -0,0 +1,187 @@
+package export
+
+import (
+	"bytes"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testList(name string, cidrs ...string) List {
+	prefixes := make([]netip.Prefix, len(cidrs))
+	for i, c := range cidrs {
+		prefixes[i] = netip.MustParsePrefix(c)
+	}
+	return NewList(name, []string{"githubhooks"}, prefixes)
+}
+
+func render(t *testing.T, format string, l List, action string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, format, l, Options{Action: action}))
+	return buf.String()
+}
+
+const testHeader = "# gh-hooks: 2 IPv4 and 1 IPv6 prefixes from githubhooks, generated by ip-to-cloudprovider\n"
+
+func TestNewList(t *testing.T) {
+	l := testList("x", "2001:db8::/32", "192.0.2.0/24", "198.51.100.0/24")
+	assert.Equal(t, "192.0.2.0/24", l.IPv4[0].String())
+	assert.Len(t, l.IPv4, 2)
+	assert.Len(t, l.IPv6, 1)
+	assert.Equal(t, "2001:db8::/32", l.Prefixes()[2].String())
+}
+
+func TestHostFormats(t *testing.T) {
+	l := testList("gh-hooks", "192.0.2.0/24", "198.51.100.0/24", "2001:db8::/32")
+
+	tests := []struct {
+		format string
+		action string
+		want   string
+	}{
+		{"iptables", ActionAllow, `# hook it up with: iptables -A INPUT -j gh-hooks
+iptables -N gh-hooks 2>/dev/null || iptables -F gh-hooks
+iptables -A gh-hooks -s 192.0.2.0/24 -j ACCEPT
+iptables -A gh-hooks -s 198.51.100.0/24 -j ACCEPT
+ip6tables -N gh-hooks 2>/dev/null || ip6tables -F gh-hooks
+ip6tables -A gh-hooks -s 2001:db8::/32 -j ACCEPT
+`},
+		{"nftables", ActionDeny, `# match with: ip saddr @gh_hooks_v4 drop
+set gh_hooks_v4 {
+	type ipv4_addr
+	flags interval
+	auto-merge
+	elements = {
+		192.0.2.0/24,
+		198.51.100.0/24
+	}
+}
+# match with: ip6 saddr @gh_hooks_v6 drop
+set gh_hooks_v6 {
+	type ipv6_addr
+	flags interval
+	auto-merge
+	elements = {
+		2001:db8::/32
+	}
+}
+`},
+		{"ipset", ActionAllow, `# match with: iptables -A INPUT -m set --match-set gh-hooks-v4 src -j ACCEPT
+create gh-hooks-v4 hash:net family inet maxelem 65536 -exist
+flush gh-hooks-v4
+add gh-hooks-v4 192.0.2.0/24
+add gh-hooks-v4 198.51.100.0/24
+# match with: ip6tables -A INPUT -m set --match-set gh-hooks-v6 src -j ACCEPT
+create gh-hooks-v6 hash:net family inet6 maxelem 65536 -exist
+flush gh-hooks-v6
+add gh-hooks-v6 2001:db8::/32
+`},
+		{"nginx", ActionAllow, `allow 192.0.2.0/24;
+allow 198.51.100.0/24;
+allow 2001:db8::/32;
+deny all;
+`},
+		{"nginx", ActionDeny, `deny 192.0.2.0/24;
+deny 198.51.100.0/24;
+deny 2001:db8::/32;
+`},
+		{"apache", ActionAllow, `<RequireAny>
+    Require ip 192.0.2.0/24
+    Require ip 198.51.100.0/24
+    Require ip 2001:db8::/32
+</RequireAny>
+`},
+		{"apache", ActionDeny, `<RequireAll>
+    Require all granted
+    Require not ip 192.0.2.0/24
+    Require not ip 198.51.100.0/24
+    Require not ip 2001:db8::/32
+</RequireAll>
+`},
+		{"haproxy", ActionDeny, `# acl gh_hooks src -f /etc/haproxy/gh-hooks.lst
+# http-request deny if gh_hooks
+192.0.2.0/24
+198.51.100.0/24
+2001:db8::/32
+`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.format+" "+tc.action, func(t *testing.T) {
+			assert.Equal(t, testHeader+tc.want, render(t, tc.format, l, tc.action))
+		})
+	}
+}
+
+func TestHostFormats_SingleFamily(t *testing.T) {
+	l := testList("web", "192.0.2.0/24")
+	for _, format := range []string{"iptables", "nftables", "ipset"} {
+		out := render(t, format, l, ActionAllow)
+		assert.NotContains(t, out, "ip6", format)
+		assert.NotContains(t, out, "web_v6", format)
+		assert.NotContains(t, out, "web-v6", format)
+	}
+}
+
+func TestRender_DefaultsToAllow(t *testing.T) {
+	out := render(t, "nginx", testList("x", "192.0.2.0/24"), "")
+	assert.Contains(t, out, "allow 192.0.2.0/24;")
+	assert.Contains(t, out, "deny all;")
+}
+
+func TestRender_Errors(t *testing.T) {
+	l := testList("x", "192.0.2.0/24")
+	tests := []struct {
+		name    string
+		format  string
+		list    List
+		action  string
+		wantErr string
+	}{
+		{"unknown format", "pf", l, ActionAllow, `unknown export format "pf"`},
+		{"unknown action", "nginx", l, "reject", `unknown action "reject"`},
+		{"missing name", "nginx", testList("", "192.0.2.0/24"), ActionAllow, "list has no name"},
+		{"chain name too long", "iptables", testList(strings.Repeat("a", 29), "192.0.2.0/24"), ActionAllow, "longer than 28 characters"},
+		{"set name too long", "ipset", testList(strings.Repeat("a", 29), "192.0.2.0/24"), ActionAllow, "longer than 31 characters"},
+		{"chain name unsafe for shell", "iptables", testList("x; rm -rf /", "192.0.2.0/24"), ActionAllow, "may only contain letters"},
+		{"set name unsafe for shell", "ipset", testList("$(reboot)", "192.0.2.0/24"), ActionAllow, "may only contain letters"},
+		{"GCP rule name too long", "gcp-firewall", testList(strings.Repeat("a", 59), "192.0.2.0/24"), ActionAllow, "longer than 63 characters"},
+		{"label value too long", "k8s-networkpolicy", testList(strings.Repeat("a", 64), "192.0.2.0/24"), ActionAllow, "longer than 63 characters"},
+		{"calico label value too long", "calico", testList(strings.Repeat("a", 64), "192.0.2.0/24"), ActionAllow, "longer than 63 characters"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Render(&bytes.Buffer{}, tc.format, tc.list, Options{Action: tc.action})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestFormats(t *testing.T) {
+	var names []string
+	for _, f := range Formats() {
+		names = append(names, f.Name)
+		assert.NotEmpty(t, f.Description, f.Name)
+	}
+	assert.IsIncreasing(t, names)
+	for _, want := range []string{
+		"apache", "haproxy", "ipset", "iptables", "nftables", "nginx",
+		"aws-sg", "aws-waf", "azure-nsg", "calico", "gcp-firewall", "k8s-networkpolicy", "terraform", "tfvars",
+		"mmdb", "lookup-csv", "zeek-intel", "suricata-categories", "suricata-iprep",
+	} {
+		assert.Contains(t, names, want)
+	}
+	assert.Nil(t, ByName("pf"))
+}
+
+func TestIdentifier(t *testing.T) {
+	assert.Equal(t, "gh_hooks", identifier("gh-hooks"))
+	assert.Equal(t, "_365", identifier("365"))
+	assert.Equal(t, "a_b_c", identifier("a.b c"))
+}
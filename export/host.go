@@ -0,0 +1,189 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"net/netip"
+	"regexp"
+)
+
+// Kernel limits on object names, excluding the terminating NUL.
+const (
+	maxChainName = 28 // iptables chains
+	maxSetName   = 31 // ipset sets
+)
+
+// shellName matches names that are safe to print unquoted into the shell
+// commands the iptables and ipset formats emit.
+var shellName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// checkShellName fails when a name would need quoting in shell output.
+func checkShellName(kind, name string) error {
+	if !shellName.MatchString(name) {
+		return fmt.Errorf("%s name %q may only contain letters, digits, '_' and '-'; choose a different --name", kind, name)
+	}
+	return nil
+}
+
+func init() {
+	Register(Format{Name: "iptables", Description: "iptables/ip6tables commands filling a dedicated chain", Render: renderIPTables})
+	Register(Format{Name: "nftables", Description: "nftables interval sets to include in a table", Render: renderNFTables})
+	Register(Format{Name: "ipset", Description: "ipset restore file with hash:net sets", Render: renderIPSet})
+	Register(Format{Name: "nginx", Description: "nginx allow/deny directives", Render: renderNginx})
+	Register(Format{Name: "apache", Description: "Apache 2.4 Require ip block", Render: renderApache})
+	Register(Format{Name: "haproxy", Description: "HAProxy ACL file, one prefix per line", Render: renderHAProxy})
+}
+
+// verdict picks the word for the list's action.
+func verdict(opts Options, allow, deny string) string {
+	if opts.Action == ActionDeny {
+		return deny
+	}
+	return allow
+}
+
+// family pairs a prefix list with the names a format uses for its family.
+type family struct {
+	prefixes []netip.Prefix
+	tool     string // iptables binary
+	nftMatch string // nftables address expression
+	nftType  string
+	inet     string // ipset family
+	suffix   string
+}
+
+func families(l List) []family {
+	return []family{
+		{l.IPv4, "iptables", "ip", "ipv4_addr", "inet", "v4"},
+		{l.IPv6, "ip6tables", "ip6", "ipv6_addr", "inet6", "v6"},
+	}
+}
+
+// renderIPTables writes a shell script that creates (or flushes) a chain per
+// family and appends one rule per prefix. The chain still has to be jumped to
+// from INPUT or FORWARD.
+func renderIPTables(w io.Writer, l List, opts Options) error {
+	if err := checkLength("iptables chain", l.Name, maxChainName); err != nil {
+		return err
+	}
+	if err := checkShellName("iptables chain", l.Name); err != nil {
+		return err
+	}
+	target := verdict(opts, "ACCEPT", "DROP")
+	ew := &errWriter{w: w}
+	ew.printf("# %s\n", header(l))
+	ew.printf("# hook it up with: iptables -A INPUT -j %s\n", l.Name)
+	for _, f := range families(l) {
+		if len(f.prefixes) == 0 {
+			continue
+		}
+		ew.printf("%s -N %s 2>/dev/null || %s -F %s\n", f.tool, l.Name, f.tool, l.Name)
+		for _, p := range f.prefixes {
+			ew.printf("%s -A %s -s %s -j %s\n", f.tool, l.Name, p, target)
+		}
+	}
+	return ew.err
+}
+
+// renderNFTables writes one interval set per family, meant to be included
+// inside a table definition. Empty sets are left out because nft rejects an
+// empty element list.
+func renderNFTables(w io.Writer, l List, opts Options) error {
+	id := identifier(l.Name)
+	ew := &errWriter{w: w}
+	ew.printf("# %s\n", header(l))
+	for _, f := range families(l) {
+		if len(f.prefixes) == 0 {
+			continue
+		}
+		set := id + "_" + f.suffix
+		ew.printf("# match with: %s saddr @%s %s\n", f.nftMatch, set, verdict(opts, "accept", "drop"))
+		ew.printf("set %s {\n\ttype %s\n\tflags interval\n\tauto-merge\n\telements = {\n", set, f.nftType)
+		for i, p := range f.prefixes {
+			sep := ","
+			if i == len(f.prefixes)-1 {
+				sep = ""
+			}
+			ew.printf("\t\t%s%s\n", p, sep)
+		}
+		ew.printf("\t}\n}\n")
+	}
+	return ew.err
+}
+
+// renderIPSet writes an `ipset restore` file with one hash:net set per
+// family. Existing sets are flushed so the file can be reapplied.
+func renderIPSet(w io.Writer, l List, opts Options) error {
+	if err := checkLength("ipset", l.Name+"-v4", maxSetName); err != nil {
+		return err
+	}
+	if err := checkShellName("ipset", l.Name); err != nil {
+		return err
+	}
+	ew := &errWriter{w: w}
+	ew.printf("# %s\n", header(l))
+	for _, f := range families(l) {
+		if len(f.prefixes) == 0 {
+			continue
+		}
+		set := l.Name + "-" + f.suffix
+		maxElem := max(65536, len(f.prefixes))
+		ew.printf("# match with: %s -A INPUT -m set --match-set %s src -j %s\n", f.tool, set, verdict(opts, "ACCEPT", "DROP"))
+		ew.printf("create %s hash:net family %s maxelem %d -exist\n", set, f.inet, maxElem)
+		ew.printf("flush %s\n", set)
+		for _, p := range f.prefixes {
+			ew.printf("add %s %s\n", set, p)
+		}
+	}
+	return ew.err
+}
+
+// renderNginx writes allow or deny directives for an http, server or location
+// block. An allowlist ends in "deny all" so nothing else gets through.
+func renderNginx(w io.Writer, l List, opts Options) error {
+	directive := verdict(opts, "allow", "deny")
+	ew := &errWriter{w: w}
+	ew.printf("# %s\n", header(l))
+	for _, p := range l.Prefixes() {
+		ew.printf("%s %s;\n", directive, p)
+	}
+	if opts.Action == ActionAllow {
+		ew.printf("deny all;\n")
+	}
+	return ew.err
+}
+
+// renderApache writes an Apache 2.4 authorization block: RequireAny over the
+// prefixes for an allowlist, RequireAll with negated matches for a blocklist.
+func renderApache(w io.Writer, l List, opts Options) error {
+	ew := &errWriter{w: w}
+	ew.printf("# %s\n", header(l))
+	if opts.Action == ActionDeny {
+		ew.printf("<RequireAll>\n    Require all granted\n")
+		for _, p := range l.Prefixes() {
+			ew.printf("    Require not ip %s\n", p)
+		}
+		ew.printf("</RequireAll>\n")
+		return ew.err
+	}
+	ew.printf("<RequireAny>\n")
+	for _, p := range l.Prefixes() {
+		ew.printf("    Require ip %s\n", p)
+	}
+	ew.printf("</RequireAny>\n")
+	return ew.err
+}
+
+// renderHAProxy writes an ACL file with one prefix per line, to be loaded
+// with `acl <name> src -f <file>`.
+func renderHAProxy(w io.Writer, l List, opts Options) error {
+	id := identifier(l.Name)
+	ew := &errWriter{w: w}
+	ew.printf("# %s\n", header(l))
+	ew.printf("# acl %s src -f /etc/haproxy/%s.lst\n", id, l.Name)
+	ew.printf("# http-request deny if %s%s\n", verdict(opts, "!", ""), id)
+	for _, p := range l.Prefixes() {
+		ew.printf("%s\n", p)
+	}
+	return ew.err
+}
@@ -0,0 +1,332 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/BenjiTrapp/ip-to-cloudprovider/provider"
+)
+
+// The MaxMind DB format is specified at
+// https://maxmind.github.io/MaxMind-DB/. A database is a binary search tree
+// over the address bits, a data section holding the records the tree's leaves
+// point to, and a metadata map after a fixed marker. IPv4 prefixes live in
+// the ::/96 subtree of an IPv6 tree, which is where readers look them up.
+
+// mmdbDatabaseType is recorded in the metadata; readers only display it.
+const mmdbDatabaseType = "ip-to-cloudprovider"
+
+// mmdbMetadataMarker precedes the metadata map at the end of the file.
+var mmdbMetadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// Data section types.
+const (
+	mmdbString = 2
+	mmdbMap    = 7
+	mmdbUint16 = 5
+	mmdbUint32 = 6
+	mmdbUint64 = 9
+	mmdbArray  = 11
+)
+
+func init() {
+	Register(Format{Name: "mmdb", Description: "MaxMind DB with provider and per-prefix metadata (binary)", Render: renderMMDB, PerPrefix: true})
+}
+
+// mmdbNode is a node of the search tree under construction. data indexes
+// the record written for the node's prefix, plus one; zero means none.
+type mmdbNode struct {
+	children [2]*mmdbNode
+	data     int
+}
+
+func (n *mmdbNode) internal() bool {
+	return n.children[0] != nil || n.children[1] != nil
+}
+
+// renderMMDB writes the list as a MaxMind DB. Each prefix maps to a record
+// with its provider and metadata; a lookup returns the same record scan
+// would: the local inventory's if it covers the address, else that of the
+// longest matching prefix. Of identical prefixes the first in the list wins.
+func renderMMDB(w io.Writer, l List, opts Options) error {
+	var local []netip.Prefix
+	for _, p := range l.Prefixes() {
+		if attrOf(l, p).Provider == provider.InventoryProviderName {
+			local = append(local, p)
+		}
+	}
+
+	root := &mmdbNode{}
+	var records [][]byte
+	index := make(map[string]int) // encoded record -> data index
+	for _, p := range l.Prefixes() {
+		if attrOf(l, p).Provider != provider.InventoryProviderName && mmdbCovered(local, p) {
+			continue // the inventory entry around it wins
+		}
+		var enc mmdbEncoder
+		enc.value(mmdbRecord(p, l))
+		rec := string(enc.Bytes())
+		idx, ok := index[rec]
+		if !ok {
+			records = append(records, enc.Bytes())
+			idx = len(records)
+			index[rec] = idx
+		}
+		mmdbInsert(root, p, idx)
+	}
+	mmdbPushDown(root, 0)
+
+	// Number the internal nodes breadth first; the root is node 0.
+	nodes := []*mmdbNode{root}
+	ids := map[*mmdbNode]int{root: 0}
+	for i := 0; i < len(nodes); i++ {
+		for _, c := range nodes[i].children {
+			if c != nil && c.internal() {
+				ids[c] = len(nodes)
+				nodes = append(nodes, c)
+			}
+		}
+	}
+
+	// Lay out the data section, remembering each record's offset.
+	var data bytes.Buffer
+	offsets := make([]int, len(records)+1)
+	for i, rec := range records {
+		offsets[i+1] = data.Len()
+		data.Write(rec)
+	}
+
+	nodeCount := len(nodes)
+	recordSize := 24
+	for _, size := range []int{24, 28, 32} {
+		recordSize = size
+		if uint64(nodeCount+16+data.Len()) < 1<<size {
+			break
+		}
+	}
+	record := func(n *mmdbNode, bit int) uint32 {
+		c := n.children[bit]
+		switch {
+		case c != nil && c.internal():
+			return uint32(ids[c])
+		case c != nil && c.data > 0:
+			return uint32(nodeCount + 16 + offsets[c.data])
+		case c == nil && n.data > 0:
+			return uint32(nodeCount + 16 + offsets[n.data])
+		}
+		return uint32(nodeCount) // no data
+	}
+
+	var out bytes.Buffer
+	for _, n := range nodes {
+		writeMMDBNode(&out, recordSize, record(n, 0), record(n, 1))
+	}
+	out.Write(make([]byte, 16)) // data section separator
+	data.WriteTo(&out)
+	out.Write(mmdbMetadataMarker)
+
+	built := opts.BuildTime
+	if built.IsZero() {
+		built = time.Now()
+	}
+	var meta mmdbEncoder
+	meta.value(map[string]any{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(recordSize),
+		"ip_version":                  uint16(6),
+		"database_type":               mmdbDatabaseType,
+		"languages":                   []string{"en"},
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(built.Unix()),
+		"description":                 map[string]string{"en": header(l)},
+	})
+	meta.WriteTo(&out)
+
+	_, err := out.WriteTo(w)
+	return err
+}
+
+// mmdbRecord builds the data stored for a prefix: the provider, and the
+// metadata it was published with.
+func mmdbRecord(p netip.Prefix, l List) map[string]any {
+	attr := attrOf(l, p)
+	rec := map[string]any{"provider": attr.Provider}
+	if attr.DisplayName != "" {
+		rec["display_name"] = attr.DisplayName
+	}
+	if attr.Category != "" {
+		rec["category"] = attr.Category
+	}
+	if m := attr.Meta; m != nil {
+		for key, value := range map[string]string{
+			"region": m.Region, "country": m.Country, "city": m.City,
+			"name": m.Name, "owner": m.Owner, "environment": m.Environment,
+		} {
+			if value != "" {
+				rec[key] = value
+			}
+		}
+		if m.ASN > 0 {
+			rec["asn"] = uint32(m.ASN)
+		}
+		if len(m.Tags) > 0 {
+			rec["services"] = m.Tags
+		}
+		if len(m.Labels) > 0 {
+			rec["labels"] = m.Labels
+		}
+	}
+	return rec
+}
+
+// mmdbCovered reports whether p lies within one of the prefixes.
+func mmdbCovered(prefixes []netip.Prefix, p netip.Prefix) bool {
+	for _, q := range prefixes {
+		if q.Bits() <= p.Bits() && q.Contains(p.Addr()) {
+			return true
+		}
+	}
+	return false
+}
+
+// mmdbInsert adds a prefix to the tree, mapping IPv4 into ::/96. An existing
+// record for the same prefix is kept.
+func mmdbInsert(root *mmdbNode, p netip.Prefix, data int) {
+	bits := p.Bits()
+	addr := p.Addr().As16()
+	if p.Addr().Is4() {
+		addr = [16]byte{}
+		v4 := p.Addr().As4()
+		copy(addr[12:], v4[:])
+		bits += 96
+	}
+	n := root
+	for i := 0; i < bits; i++ {
+		bit := addr[i/8] >> (7 - i%8) & 1
+		if n.children[bit] == nil {
+			n.children[bit] = &mmdbNode{}
+		}
+		n = n.children[bit]
+	}
+	if n.data == 0 {
+		n.data = data
+	}
+}
+
+// mmdbPushDown gives every node without a record of its own the record of
+// its closest ancestor, so that a more specific prefix nested in a larger one
+// leaves the rest of the larger prefix intact.
+func mmdbPushDown(n *mmdbNode, inherited int) {
+	if n.data == 0 {
+		n.data = inherited
+	}
+	for _, c := range n.children {
+		if c != nil {
+			mmdbPushDown(c, n.data)
+		}
+	}
+}
+
+// writeMMDBNode writes one node's left and right records.
+func writeMMDBNode(out *bytes.Buffer, recordSize int, left, right uint32) {
+	switch recordSize {
+	case 24:
+		out.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left), byte(right >> 16), byte(right >> 8), byte(right)})
+	case 28:
+		out.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left),
+			byte(left>>24&0x0f)<<4 | byte(right>>24&0x0f),
+			byte(right >> 16), byte(right >> 8), byte(right)})
+	default:
+		out.Write(binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, left), right))
+	}
+}
+
+// mmdbEncoder writes values in the data section encoding.
+type mmdbEncoder struct {
+	bytes.Buffer
+}
+
+// control writes a field's type and size.
+func (e *mmdbEncoder) control(typ, size int) {
+	var ctrl byte
+	if typ <= 7 {
+		ctrl = byte(typ) << 5
+	}
+	var ext []byte
+	switch {
+	case size < 29:
+		ctrl |= byte(size)
+	case size < 29+256:
+		ctrl |= 29
+		ext = []byte{byte(size - 29)}
+	case size < 285+65536:
+		ctrl |= 30
+		s := size - 285
+		ext = []byte{byte(s >> 8), byte(s)}
+	default:
+		ctrl |= 31
+		s := size - 65821
+		ext = []byte{byte(s >> 16), byte(s >> 8), byte(s)}
+	}
+	e.WriteByte(ctrl)
+	if typ > 7 {
+		e.WriteByte(byte(typ - 7))
+	}
+	e.Write(ext)
+}
+
+// uint writes an unsigned integer with leading zero bytes dropped.
+func (e *mmdbEncoder) uint(typ int, v uint64) {
+	b := binary.BigEndian.AppendUint64(nil, v)
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+	e.control(typ, len(b))
+	e.Write(b)
+}
+
+// value writes the types the records and metadata use. Map keys are sorted
+// so the output is deterministic.
+func (e *mmdbEncoder) value(v any) {
+	switch v := v.(type) {
+	case string:
+		e.control(mmdbString, len(v))
+		e.WriteString(v)
+	case uint16:
+		e.uint(mmdbUint16, uint64(v))
+	case uint32:
+		e.uint(mmdbUint32, uint64(v))
+	case uint64:
+		e.uint(mmdbUint64, v)
+	case []string:
+		e.control(mmdbArray, len(v))
+		for _, s := range v {
+			e.value(s)
+		}
+	case map[string]string:
+		m := make(map[string]any, len(v))
+		for k, s := range v {
+			m[k] = s
+		}
+		e.value(m)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		e.control(mmdbMap, len(keys))
+		for _, k := range keys {
+			e.value(k)
+			e.value(v[k])
+		}
+	default:
+		panic(fmt.Sprintf("mmdb: unsupported type %T", v))
+	}
+}
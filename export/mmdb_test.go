@@ -0,0 +1,230 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BenjiTrapp/ip-to-cloudprovider/provider"
+)
+
+// mmdbReader is a minimal reader following the MaxMind DB spec, enough to
+// check that generated databases resolve as a standard reader would.
+type mmdbReader struct {
+	buf        []byte
+	meta       map[string]any
+	nodeCount  int
+	recordSize int
+	dataStart  int
+}
+
+func openMMDB(t *testing.T, buf []byte) *mmdbReader {
+	t.Helper()
+	at := bytes.LastIndex(buf, mmdbMetadataMarker)
+	require.GreaterOrEqual(t, at, 0, "metadata marker")
+	meta, _ := mmdbDecode(t, buf, at+len(mmdbMetadataMarker))
+	r := &mmdbReader{buf: buf, meta: meta.(map[string]any)}
+	r.nodeCount = int(r.meta["node_count"].(uint64))
+	r.recordSize = int(r.meta["record_size"].(uint64))
+	r.dataStart = r.nodeCount*r.recordSize/4 + 16
+	return r
+}
+
+func (r *mmdbReader) record(node, bit int) int {
+	switch r.recordSize {
+	case 24:
+		b := r.buf[node*6:]
+		if bit == 1 {
+			b = b[3:]
+		}
+		return int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+	case 28:
+		b := r.buf[node*7:]
+		if bit == 0 {
+			return int(b[3]&0xf0)<<20 | int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+		}
+		return int(b[3]&0x0f)<<24 | int(b[4])<<16 | int(b[5])<<8 | int(b[6])
+	}
+	return int(binary.BigEndian.Uint32(r.buf[node*8+bit*4:]))
+}
+
+// lookup returns the record for addr, or nil.
+func (r *mmdbReader) lookup(t *testing.T, addr netip.Addr) map[string]any {
+	t.Helper()
+	ip := addr.As16()
+	if addr.Is4() {
+		ip = [16]byte{}
+		v4 := addr.As4()
+		copy(ip[12:], v4[:])
+	}
+	node := 0
+	for i := 0; i < 128 && node < r.nodeCount; i++ {
+		node = r.record(node, int(ip[i/8]>>(7-i%8)&1))
+	}
+	if node == r.nodeCount {
+		return nil
+	}
+	require.Greater(t, node, r.nodeCount, "lookup ended inside the tree")
+	v, _ := mmdbDecode(t, r.buf, r.dataStart+node-r.nodeCount-16)
+	return v.(map[string]any)
+}
+
+// mmdbDecode decodes the value at pos; unsigned integers come back as uint64.
+func mmdbDecode(t *testing.T, buf []byte, pos int) (any, int) {
+	t.Helper()
+	ctrl := buf[pos]
+	pos++
+	typ := int(ctrl >> 5)
+	if typ == 0 {
+		typ = 7 + int(buf[pos])
+		pos++
+	}
+	size := int(ctrl & 0x1f)
+	switch size {
+	case 29:
+		size = 29 + int(buf[pos])
+		pos++
+	case 30:
+		size = 285 + (int(buf[pos])<<8 | int(buf[pos+1]))
+		pos += 2
+	case 31:
+		size = 65821 + (int(buf[pos])<<16 | int(buf[pos+1])<<8 | int(buf[pos+2]))
+		pos += 3
+	}
+	switch typ {
+	case mmdbString:
+		return string(buf[pos : pos+size]), pos + size
+	case mmdbUint16, mmdbUint32, mmdbUint64:
+		var v uint64
+		for _, b := range buf[pos : pos+size] {
+			v = v<<8 | uint64(b)
+		}
+		return v, pos + size
+	case mmdbMap:
+		m := make(map[string]any, size)
+		for i := 0; i < size; i++ {
+			var k, v any
+			k, pos = mmdbDecode(t, buf, pos)
+			v, pos = mmdbDecode(t, buf, pos)
+			m[k.(string)] = v
+		}
+		return m, pos
+	case mmdbArray:
+		a := make([]any, size)
+		for i := range a {
+			a[i], pos = mmdbDecode(t, buf, pos)
+		}
+		return a, pos
+	}
+	t.Fatalf("unexpected mmdb type %d", typ)
+	return nil, pos
+}
+
+func mmdbTestList() List {
+	l := testList("test", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "192.0.2.1/32", "2001:db8::/32")
+	l.Attrs = map[netip.Prefix]Attr{
+		netip.MustParsePrefix("10.0.0.0/8"): {Provider: "oracle", DisplayName: "Oracle Cloud", Category: "cloud",
+			Meta: &provider.PrefixMeta{Region: "us-phoenix-1", Tags: []string{"OCI", "OSN"}}},
+		netip.MustParsePrefix("10.1.0.0/16"): {Provider: "local", Category: "local",
+			Meta: &provider.PrefixMeta{Name: "corp-vpn", Labels: map[string]string{"site": "fra1"}}},
+		netip.MustParsePrefix("10.1.2.0/24"):   {Provider: "oracle", Category: "cloud"}, // inside the inventory entry
+		netip.MustParsePrefix("2001:db8::/32"): {Provider: "hetzner", Meta: &provider.PrefixMeta{ASN: 24940}},
+	}
+	return l
+}
+
+func TestMMDB_Lookup(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, "mmdb", mmdbTestList(), Options{}))
+	r := openMMDB(t, buf.Bytes())
+
+	tests := []struct {
+		ip   string
+		want map[string]any
+	}{
+		{"10.2.3.4", map[string]any{"provider": "oracle", "display_name": "Oracle Cloud", "category": "cloud",
+			"region": "us-phoenix-1", "services": []any{"OCI", "OSN"}}},
+		{"10.1.2.3", map[string]any{"provider": "local", "category": "local", "name": "corp-vpn",
+			"labels": map[string]any{"site": "fra1"}}},
+		{"10.255.255.255", map[string]any{"provider": "oracle", "display_name": "Oracle Cloud", "category": "cloud",
+			"region": "us-phoenix-1", "services": []any{"OCI", "OSN"}}},
+		{"2001:db8::1", map[string]any{"provider": "hetzner", "asn": uint64(24940)}},
+		{"192.0.2.1", map[string]any{"provider": "test"}},
+		{"192.0.2.2", nil},
+		{"11.0.0.1", nil},
+		{"2001:db9::1", nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.ip, func(t *testing.T) {
+			got := r.lookup(t, netip.MustParseAddr(tc.ip))
+			if tc.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestMMDB_Metadata(t *testing.T) {
+	built := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	var first, second bytes.Buffer
+	require.NoError(t, Render(&first, "mmdb", mmdbTestList(), Options{BuildTime: built}))
+	require.NoError(t, Render(&second, "mmdb", mmdbTestList(), Options{BuildTime: built}))
+	assert.Equal(t, first.Bytes(), second.Bytes(), "output is deterministic")
+
+	meta := openMMDB(t, first.Bytes()).meta
+	assert.Equal(t, uint64(6), meta["ip_version"])
+	assert.Equal(t, uint64(24), meta["record_size"])
+	assert.Equal(t, uint64(2), meta["binary_format_major_version"])
+	assert.Equal(t, uint64(built.Unix()), meta["build_epoch"])
+	assert.Equal(t, "ip-to-cloudprovider", meta["database_type"])
+	assert.Equal(t, []any{"en"}, meta["languages"])
+	assert.Contains(t, meta["description"].(map[string]any)["en"], "4 IPv4 and 1 IPv6 prefixes")
+}
+
+func TestMMDB_EncoderSizes(t *testing.T) {
+	for _, n := range []int{0, 28, 29, 284, 285, 65820, 65821, 70000} {
+		var enc mmdbEncoder
+		s := strings.Repeat("x", n)
+		enc.value(s)
+		got, end := mmdbDecode(t, enc.Bytes(), 0)
+		assert.Len(t, got, n)
+		assert.Equal(t, enc.Len(), end, "length %d", n)
+	}
+
+	var enc mmdbEncoder
+	enc.value(uint64(1) << 40)
+	got, _ := mmdbDecode(t, enc.Bytes(), 0)
+	assert.Equal(t, uint64(1)<<40, got)
+}
+
+func TestWriteMMDBNode(t *testing.T) {
+	tests := []struct {
+		size int
+		want []byte
+	}{
+		{24, []byte{0xbc, 0xde, 0xf1, 0x23, 0x45, 0x67}},
+		{28, []byte{0xbc, 0xde, 0xf1, 0xa1, 0x23, 0x45, 0x67}},
+		{32, []byte{0x0a, 0xbc, 0xde, 0xf1, 0x01, 0x23, 0x45, 0x67}},
+	}
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		writeMMDBNode(&buf, tc.size, 0x0abcdef1, 0x01234567)
+		assert.Equal(t, tc.want, buf.Bytes(), "record size %d", tc.size)
+
+		r := &mmdbReader{buf: buf.Bytes(), recordSize: tc.size}
+		if tc.size == 24 {
+			continue // the values do not fit 24 bits
+		}
+		assert.Equal(t, 0x0abcdef1, r.record(0, 0))
+		assert.Equal(t, 0x01234567, r.record(0, 1))
+	}
+}
@@ -0,0 +1,160 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// suricataMaxCategories is the number of categories Suricata's IP reputation
+// accepts when they are numbered from 1: ids must be below 60.
+const suricataMaxCategories = 59
+
+// suricataScore is the reputation score of every entry. Provider attribution
+// is not graded, so rules match on the category alone (iprep:src,<name>,>,0).
+const suricataScore = 100
+
+func init() {
+	Register(Format{Name: "lookup-csv", Description: "Splunk/Elastic CIDR lookup CSV (cidr, provider, service, region)", Render: renderLookupCSV, PerPrefix: true})
+	Register(Format{Name: "zeek-intel", Description: "Zeek Intel framework file with Intel::SUBNET indicators", Render: renderZeekIntel, PerPrefix: true})
+	Register(Format{Name: "suricata-categories", Description: "Suricata iprep categories file, one category per provider", Render: renderSuricataCategories, PerPrefix: true})
+	Register(Format{Name: "suricata-iprep", Description: "Suricata iprep reputation file (cidr, category, score)", Render: renderSuricataIPRep, PerPrefix: true})
+}
+
+// attrOf returns the attribution of a prefix, falling back to the list name
+// for prefixes without one.
+func attrOf(l List, p netip.Prefix) Attr {
+	if attr, ok := l.Attrs[p]; ok {
+		return attr
+	}
+	return Attr{Provider: l.Name}
+}
+
+// services returns a prefix's service tags joined by sep, or "".
+func (a Attr) services(sep string) string {
+	if a.Meta == nil {
+		return ""
+	}
+	return strings.Join(a.Meta.Tags, sep)
+}
+
+// region returns a prefix's region, or "".
+func (a Attr) region() string {
+	if a.Meta == nil {
+		return ""
+	}
+	return a.Meta.Region
+}
+
+// renderLookupCSV writes a CSV with a header row, for a Splunk lookup with
+// match_type CIDR(cidr) or an Elastic enrich policy of type range. Several
+// services of one prefix are separated by ";".
+func renderLookupCSV(w io.Writer, l List, opts Options) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"cidr", "provider", "service", "region"})
+	for _, p := range l.Prefixes() {
+		attr := attrOf(l, p)
+		_ = cw.Write([]string{p.String(), attr.Provider, attr.services(";"), attr.region()})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// renderZeekIntel writes a Zeek Intel framework input file. Fields are tab
+// separated, and empty ones are written as "-".
+func renderZeekIntel(w io.Writer, l List, opts Options) error {
+	ew := &errWriter{w: w}
+	ew.printf("#fields\tindicator\tindicator_type\tmeta.source\tmeta.desc\n")
+	for _, p := range l.Prefixes() {
+		attr := attrOf(l, p)
+		ew.printf("%s\tIntel::SUBNET\tip-to-cloudprovider\t%s\n", p, zeekField(zeekDesc(attr)))
+	}
+	return ew.err
+}
+
+// zeekDesc describes a prefix for meta.desc, e.g.
+// "Oracle Cloud (oracle); region us-phoenix-1; services OCI,OSN".
+func zeekDesc(attr Attr) string {
+	desc := attr.Provider
+	if attr.DisplayName != "" {
+		desc = fmt.Sprintf("%s (%s)", attr.DisplayName, attr.Provider)
+	}
+	if r := attr.region(); r != "" {
+		desc += "; region " + r
+	}
+	if s := attr.services(","); s != "" {
+		desc += "; services " + s
+	}
+	return desc
+}
+
+// zeekField makes a value safe for a tab-separated Zeek input file.
+func zeekField(s string) string {
+	s = strings.NewReplacer("\t", " ", "\n", " ").Replace(s)
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// suricataCategories numbers the providers in the list by name, starting at
+// 1. The categories and reputation files of the same selection agree on the
+// numbers, so they must be generated together.
+func suricataCategories(l List) ([]string, map[string]int, error) {
+	var names []string
+	for _, p := range l.Prefixes() {
+		if name := attrOf(l, p).Provider; !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	if len(names) > suricataMaxCategories {
+		return nil, nil, fmt.Errorf("suricata supports at most %d reputation categories, the selection has %d providers", suricataMaxCategories, len(names))
+	}
+	ids := make(map[string]int, len(names))
+	for i, name := range names {
+		ids[name] = i + 1
+	}
+	return names, ids, nil
+}
+
+// renderSuricataCategories writes the categories file referenced by
+// reputation-categories-file: id, short name, description.
+func renderSuricataCategories(w io.Writer, l List, opts Options) error {
+	names, _, err := suricataCategories(l)
+	if err != nil {
+		return err
+	}
+	display := make(map[string]string)
+	for _, attr := range l.Attrs {
+		if attr.DisplayName != "" {
+			display[attr.Provider] = attr.DisplayName
+		}
+	}
+	ew := &errWriter{w: w}
+	for i, name := range names {
+		desc := display[name]
+		if desc == "" {
+			desc = name
+		}
+		ew.printf("%d,%s,%s\n", i+1, name, strings.ReplaceAll(desc, ",", " "))
+	}
+	return ew.err
+}
+
+// renderSuricataIPRep writes a reputation file listed under
+// reputation-files: prefix, category id, score.
+func renderSuricataIPRep(w io.Writer, l List, opts Options) error {
+	_, ids, err := suricataCategories(l)
+	if err != nil {
+		return err
+	}
+	ew := &errWriter{w: w}
+	for _, p := range l.Prefixes() {
+		ew.printf("%s,%d,%d\n", p, ids[attrOf(l, p).Provider], suricataScore)
+	}
+	return ew.err
+}
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// siemTestList attributes two prefixes to oracle with metadata and leaves
+// 192.0.2.1 without attribution.
+func siemTestList() List {
+	l := testList("test", "10.0.0.0/8", "10.1.0.0/16", "192.0.2.1/32", "2001:db8::/32")
+	l.Attrs = map[netip.Prefix]Attr{
+		netip.MustParsePrefix("10.0.0.0/8"): {Provider: "oracle", DisplayName: "Oracle Cloud",
+			Meta: mmdbTestList().Attrs[netip.MustParsePrefix("10.0.0.0/8")].Meta},
+		netip.MustParsePrefix("10.1.0.0/16"):   {Provider: "oracle", DisplayName: "Oracle Cloud"},
+		netip.MustParsePrefix("2001:db8::/32"): {Provider: "hetzner", DisplayName: "Hetzner, Online"},
+	}
+	return l
+}
+
+func TestLookupCSV(t *testing.T) {
+	assert.Equal(t, `cidr,provider,service,region
+10.0.0.0/8,oracle,OCI;OSN,us-phoenix-1
+10.1.0.0/16,oracle,,
+192.0.2.1/32,test,,
+2001:db8::/32,hetzner,,
+`, render(t, "lookup-csv", siemTestList(), ActionAllow))
+}
+
+func TestZeekIntel(t *testing.T) {
+	assert.Equal(t, "#fields\tindicator\tindicator_type\tmeta.source\tmeta.desc\n"+
+		"10.0.0.0/8\tIntel::SUBNET\tip-to-cloudprovider\tOracle Cloud (oracle); region us-phoenix-1; services OCI,OSN\n"+
+		"10.1.0.0/16\tIntel::SUBNET\tip-to-cloudprovider\tOracle Cloud (oracle)\n"+
+		"192.0.2.1/32\tIntel::SUBNET\tip-to-cloudprovider\ttest\n"+
+		"2001:db8::/32\tIntel::SUBNET\tip-to-cloudprovider\tHetzner, Online (hetzner)\n",
+		render(t, "zeek-intel", siemTestList(), ActionAllow))
+
+	assert.Equal(t, "-", zeekField(""))
+	assert.Equal(t, "a b c", zeekField("a\tb\nc"))
+}
+
+func TestSuricata(t *testing.T) {
+	l := siemTestList()
+	assert.Equal(t, `1,hetzner,Hetzner  Online
+2,oracle,Oracle Cloud
+3,test,test
+`, render(t, "suricata-categories", l, ActionAllow))
+
+	assert.Equal(t, `10.0.0.0/8,2,100
+10.1.0.0/16,2,100
+192.0.2.1/32,3,100
+2001:db8::/32,1,100
+`, render(t, "suricata-iprep", l, ActionAllow))
+}
+
+func TestSuricata_TooManyCategories(t *testing.T) {
+	l := List{Name: "many", Attrs: map[netip.Prefix]Attr{}}
+	for i := 0; i <= suricataMaxCategories; i++ {
+		p := netip.PrefixFrom(netip.AddrFrom4([4]byte{10, 0, byte(i), 0}), 24)
+		l.IPv4 = append(l.IPv4, p)
+		l.Attrs[p] = Attr{Provider: fmt.Sprintf("p%02d", i)}
+	}
+	for _, format := range []string{"suricata-categories", "suricata-iprep"} {
+		err := Render(&bytes.Buffer{}, format, l, Options{})
+		require.Error(t, err, format)
+		assert.Contains(t, err.Error(), "at most 59 reputation categories")
+	}
+
+	// The largest accepted selection ends at id 59.
+	delete(l.Attrs, l.IPv4[suricataMaxCategories])
+	l.IPv4 = l.IPv4[:suricataMaxCategories]
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, "suricata-categories", l, Options{}))
+	assert.True(t, strings.HasSuffix(buf.String(), "\n59,p58,p58\n"), buf.String())
+}
@@ -2,17 +2,27 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"math/big"
 	"net"
+	"net/netip"
 	"os"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
+	"github.com/BenjiTrapp/ip-to-cloudprovider/assets"
+	"github.com/BenjiTrapp/ip-to-cloudprovider/cidr"
+	"github.com/BenjiTrapp/ip-to-cloudprovider/export"
 	"github.com/BenjiTrapp/ip-to-cloudprovider/provider"
 	"github.com/BenjiTrapp/ip-to-cloudprovider/reputation"
 	"github.com/BenjiTrapp/ip-to-cloudprovider/shodan"
@@ -38,6 +48,23 @@ var (
 	checkRep         bool
 	repConfigPath    string
 	shodanConfigPath string
+	providersConfig  string
+	inventoryPath    string
+	assetFiles       []string
+	categoryFilter   []string
+	priorityList     []string
+)
+
+// usesProviders marks the commands that read the registry, and with it the
+// providers config; only those fail when the config is broken.
+const usesProviders = "uses-providers"
+
+// The providers config, read before the command tree is built so that its
+// providers get their own subcommands. A load error is kept until a command
+// that needs the config runs.
+var (
+	providersCfg    provider.Config
+	providersCfgErr error
 )
 
 func main() {
@@ -50,6 +77,9 @@ func main() {
 				fmt.Print(banner)
 				fmt.Println("-------------------------------------------------------")
 			}
+			if cmd.Annotations[usesProviders] != "" {
+				loadConfiguredProviders()
+			}
 		},
 	}
 
@@ -57,6 +87,9 @@ func main() {
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress banner output")
 	rootCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "j", false, "Output results as JSON")
 	rootCmd.PersistentFlags().StringVar(&dataDir, "data-dir", provider.DefaultDataDir(), "Directory for IP range data files")
+	rootCmd.PersistentFlags().StringVar(&providersConfig, "providers-config", "", "Path to config file with user-defined providers (default: per-user config dir)")
+	rootCmd.PersistentFlags().StringVar(&inventoryPath, "inventory", os.Getenv("IP2CP_INVENTORY"), "CSV or YAML inventory of your own CIDRs, matched before any provider")
+	rootCmd.PersistentFlags().StringSliceVar(&priorityList, "priority", nil, "Providers that win when ranges overlap, highest first (e.g. googlebot,googlecloud,google)")
 
 	// --update-all / -a flag on root
 	var updateAll bool
@@ -83,6 +116,8 @@ Examples:
   ip-to-cloudprovider s 8.8.8.8 1.1.1.1 13.224.0.1
   ip-to-cloudprovider scan --stats -f ips.txt
   ip-to-cloudprovider scan 1.2.3.4 --reputation
+  ip-to-cloudprovider scan 66.249.66.1 --category bot
+  ip-to-cloudprovider scan -f ips.txt --assets addresses.json --assets enis.json
   echo "8.8.8.8" | ip-to-cloudprovider scan -q -j
   cat ips.txt | ip-to-cloudprovider scan -q -j`,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -99,6 +134,8 @@ Examples:
 	scanCmd.Flags().BoolVar(&showStats, "stats", false, "Show summary statistics after scan")
 	scanCmd.Flags().BoolVarP(&checkRep, "reputation", "r", false, "Also check each IP against threat-intel sources (DNSBLs, AbuseIPDB)")
 	scanCmd.Flags().StringVar(&repConfigPath, "reputation-config", "", "Path to reputation config file (default: per-user config dir)")
+	scanCmd.Flags().StringSliceVar(&categoryFilter, "category", nil, "Only match providers in these categories (cloud, cdn, bot, ci, proxy, ai, ...)")
+	scanCmd.Flags().StringSliceVar(&assetFiles, "assets", nil, "Exported AWS/GCP/Azure address inventory (JSON) marking IPs as your own; repeatable")
 
 	// scan-file command (kept for backward compat)
 	scanFileCmd := &cobra.Command{
@@ -122,6 +159,129 @@ Examples:
 			listProviders()
 		},
 	}
+	listCmd.Flags().StringSliceVar(&categoryFilter, "category", nil, "Only list providers in these categories")
+
+	// overlaps command
+	overlapsCmd := &cobra.Command{
+		Use:   "overlaps [provider...]",
+		Short: "Show the address space shared by pairs of providers",
+		Long: `Compute, for every pair of providers, the address space both publish.
+
+Uses the stored provider data; nothing is downloaded. Name providers to only
+compare those, or restrict the comparison with --category. Text output is a
+matrix of shared IPv4 addresses followed by the overlapping pairs; JSON output
+lists the shared prefixes of every pair.
+
+Examples:
+  ip-to-cloudprovider overlaps
+  ip-to-cloudprovider overlaps github githubactions microsoft
+  ip-to-cloudprovider overlaps --category cdn,cloud -j`,
+		Run: func(cmd *cobra.Command, args []string) {
+			showOverlaps(args)
+		},
+	}
+	overlapsCmd.Flags().StringSliceVar(&categoryFilter, "category", nil, "Only compare providers in these categories")
+
+	// stats command
+	var statsDetail bool
+	var statsTop int
+	statsCmd := &cobra.Command{
+		Use:   "stats [provider...]",
+		Short: "Show prefix and address statistics of the provider datasets",
+		Long: `Summarise each provider's stored dataset: prefix counts, address counts,
+share of publicly routable IPv4 space and prefix sizes, plus a total for the
+union of all selected providers.
+
+With --detail, also show the largest and smallest prefixes and, where the
+provider publishes them, the breakdown by region and by service.
+
+Examples:
+  ip-to-cloudprovider stats
+  ip-to-cloudprovider stats amazon oracle --detail
+  ip-to-cloudprovider stats --category cdn -j`,
+		Run: func(cmd *cobra.Command, args []string) {
+			showProviderStats(args, statsDetail, statsTop)
+		},
+	}
+	statsCmd.Flags().StringSliceVar(&categoryFilter, "category", nil, "Only include providers in these categories")
+	statsCmd.Flags().BoolVar(&statsDetail, "detail", false, "Show prefix extremes and region/service breakdowns")
+	statsCmd.Flags().IntVar(&statsTop, "top", 10, "Breakdown entries shown per provider with --detail (0 = all)")
+
+	// export command
+	var exportOpts exportOptions
+	exportCmd := &cobra.Command{
+		Use:   "export [provider...]",
+		Short: "Render provider ranges as firewall, proxy or web server rules",
+		Long: `Render the stored ranges of the named providers (or of all providers in the
+--category selection) in a format a firewall, proxy or web server loads
+directly. The ranges of several providers are merged into one list.
+
+Formats:
+` + exportFormatHelp() + `
+
+--action decides whether the rules allow or block the listed ranges; --name
+sets the chain, set or ACL name (default: the provider names joined by "-",
+or the categories when selecting with --category).
+--aggregate collapses adjacent and overlapping prefixes into the fewest
+possible, and --ipv4-only / --ipv6-only restrict the output to one family.
+
+Cloud targets are split into several numbered objects when the list exceeds
+the target's per-object limit; --max-per-object changes that limit, e.g. after
+a quota increase.
+
+Examples:
+  ip-to-cloudprovider export githubhooks --format nginx -q
+  ip-to-cloudprovider export githubhooks --format ipset --name gh-hooks -o gh-hooks.ipset
+  ip-to-cloudprovider export --category bot,ai --format nftables --action deny --aggregate -q
+  ip-to-cloudprovider export cloudflare --format apache --ipv4-only -o cloudflare.conf
+  ip-to-cloudprovider export githubactions --format aws-waf --aggregate -o gha-ipsets.json
+  ip-to-cloudprovider export githubhooks --format k8s-networkpolicy -o hooks-netpol.yaml
+  ip-to-cloudprovider export --format mmdb -o ip-to-cloudprovider.mmdb`,
+		Run: func(cmd *cobra.Command, args []string) {
+			exportProviders(args, exportOpts)
+		},
+	}
+	exportCmd.Flags().StringVar(&exportOpts.format, "format", "", "Output format (required, see above)")
+	exportCmd.Flags().StringVar(&exportOpts.action, "action", export.ActionAllow, "Whether the rules allow or deny the ranges (allow, deny)")
+	exportCmd.Flags().StringVar(&exportOpts.name, "name", "", "Name of the generated chain, set or ACL")
+	exportCmd.Flags().StringVarP(&exportOpts.output, "output", "o", "", "Write to this file instead of stdout")
+	exportCmd.Flags().BoolVar(&exportOpts.aggregate, "aggregate", false, "Collapse adjacent and overlapping prefixes")
+	exportCmd.Flags().IntVar(&exportOpts.maxEntries, "max-per-object", 0, "Split cloud objects at this many prefixes instead of the target's default limit")
+	exportCmd.Flags().BoolVar(&exportOpts.ipv4Only, "ipv4-only", false, "Only export IPv4 prefixes")
+	exportCmd.Flags().BoolVar(&exportOpts.ipv6Only, "ipv6-only", false, "Only export IPv6 prefixes")
+	exportCmd.Flags().StringSliceVar(&categoryFilter, "category", nil, "Only export providers in these categories")
+	exportCmd.MarkFlagsMutuallyExclusive("ipv4-only", "ipv6-only")
+	_ = exportCmd.MarkFlagRequired("format")
+
+	// allowlist command
+	var allowlistOpts allowlistOptions
+	allowlistCmd := &cobra.Command{
+		Use:   "allowlist <provider...>",
+		Short: "Print the minimal aggregated prefix list covering some providers",
+		Long: `Merge the stored ranges of the named providers (e.g. the services a CI job
+or webhook receiver talks to) into the fewest prefixes covering exactly the
+same addresses, one per line, sorted by address.
+
+The header carries a SHA-256 checksum of the prefix lines. It only changes
+when the covered address space does, so CI can detect changes by comparing
+it, or by passing the expected value to --verify, which exits non-zero when
+the list has changed.
+
+Examples:
+  ip-to-cloudprovider allowlist githubhooks githubactions anthropic-outbound -q
+  ip-to-cloudprovider allowlist githubhooks --ipv4-only -o hooks.txt
+  ip-to-cloudprovider allowlist githubhooks -q -j | jq -r .sha256
+  ip-to-cloudprovider allowlist githubhooks -q --verify 3f1c...`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			printAllowlist(args, allowlistOpts)
+		},
+	}
+	allowlistCmd.Flags().BoolVar(&allowlistOpts.ipv4Only, "ipv4-only", false, "Only include IPv4 prefixes")
+	allowlistCmd.Flags().BoolVar(&allowlistOpts.ipv6Only, "ipv6-only", false, "Only include IPv6 prefixes")
+	allowlistCmd.Flags().StringVarP(&allowlistOpts.output, "output", "o", "", "Write the list to this file instead of stdout")
+	allowlistCmd.Flags().StringVar(&allowlistOpts.verify, "verify", "", "Expected SHA-256; exit with status 1 if the list differs")
+	allowlistCmd.MarkFlagsMutuallyExclusive("ipv4-only", "ipv6-only")
 
 	// shodan command
 	shodanCmd := &cobra.Command{
@@ -154,7 +314,20 @@ Examples:
 	shodanCmd.Flags().StringP("file", "f", "", "Read targets from file (one per line)")
 	shodanCmd.Flags().StringVar(&shodanConfigPath, "shodan-config", "", "Path to config file with the Shodan API key (default: per-user config dir)")
 
-	// Per-provider subcommands with --update flag
+	rootCmd.AddCommand(scanCmd)
+	rootCmd.AddCommand(scanFileCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(overlapsCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(allowlistCmd)
+	rootCmd.AddCommand(shodanCmd)
+
+	// Per-provider subcommands with --update flag, including the providers
+	// declared in the config file. Those may not take a command's name.
+	providersConfig = providersConfigArg(os.Args[1:])
+	registerConfiguredProviders(commandNames(rootCmd))
+	var providerCmds []*cobra.Command
 	for _, p := range provider.Registry {
 		p := p
 		cmd := &cobra.Command{
@@ -175,12 +348,11 @@ Examples:
 		}
 		cmd.Flags().BoolP("update", "u", false, fmt.Sprintf("Update %s IP ranges", p.Name))
 		rootCmd.AddCommand(cmd)
+		providerCmds = append(providerCmds, cmd)
+	}
+	for _, cmd := range append(providerCmds, rootCmd, scanCmd, scanFileCmd, listCmd, overlapsCmd, statsCmd, exportCmd, allowlistCmd) {
+		cmd.Annotations = map[string]string{usesProviders: "true"}
 	}
-
-	rootCmd.AddCommand(scanCmd)
-	rootCmd.AddCommand(scanFileCmd)
-	rootCmd.AddCommand(listCmd)
-	rootCmd.AddCommand(shodanCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -191,68 +363,155 @@ Examples:
 // Commands
 // ---------------------------------------------------------------------------
 
-func updateAllProviders() {
-	// Deduplicate GitHub meta fetches
-	githubUpdated := false
+// providersConfigArg returns the --providers-config value from the command
+// line. The config is needed before cobra parses the flags, because it
+// decides which per-provider subcommands exist.
+func providersConfigArg(args []string) string {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return ""
+		case arg == "--providers-config" && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(arg, "--providers-config="):
+			return strings.TrimPrefix(arg, "--providers-config=")
+		}
+	}
+	return ""
+}
 
-	for _, p := range provider.Registry {
-		p := p
+// commandNames returns the names and aliases of root's subcommands, plus the
+// help and completion commands cobra adds when it runs.
+func commandNames(root *cobra.Command) []string {
+	names := []string{"help", "completion"}
+	for _, cmd := range root.Commands() {
+		names = append(names, cmd.Name())
+		names = append(names, cmd.Aliases...)
+	}
+	return names
+}
 
-		// Optimize: fetch GitHub /meta once for all GitHub sub-providers
-		if provider.IsGitHubProvider(p.Name) {
-			if !githubUpdated {
-				if err := provider.UpdateGitHubAll(dataDir); err != nil {
-					fmt.Fprintf(os.Stderr, "Error updating GitHub providers: %v\n", err)
-				} else {
-					fmt.Printf("%-20s IP ranges updated successfully\n", colorizeProvider("github"))
-					fmt.Printf("%-20s IP ranges updated successfully\n", colorizeProvider("githubactions"))
-					fmt.Printf("%-20s IP ranges updated successfully\n", colorizeProvider("githubhooks"))
-					fmt.Printf("%-20s IP ranges updated successfully\n", colorizeProvider("githubpages"))
-				}
-				githubUpdated = true
-			}
-			continue
+// registerConfiguredProviders adds the user-defined providers from the config
+// file to the registry. A provider named like one of the reserved commands
+// would replace it, so that is an error. Errors are kept for
+// loadConfiguredProviders, so a broken config does not stop commands that
+// never read it.
+func registerConfiguredProviders(reserved []string) {
+	providersCfg, providersCfgErr = provider.LoadConfig(providersConfig)
+	if providersCfgErr != nil {
+		providersCfgErr = fmt.Errorf("loading providers config: %w", providersCfgErr)
+		return
+	}
+	for i, pc := range providersCfg.Providers {
+		if slices.Contains(reserved, pc.Name) {
+			providersCfgErr = fmt.Errorf("in providers config: provider #%d: name %q is taken by a command", i+1, pc.Name)
+			return
 		}
+	}
+	if err := providersCfg.Register(); err != nil {
+		providersCfgErr = fmt.Errorf("in providers config: %w", err)
+	}
+}
 
-		if err := provider.UpdateProvider(&p, dataDir); err != nil {
-			fmt.Fprintf(os.Stderr, "Error updating %s: %v\n", p.Name, err)
-			continue
+// loadConfiguredProviders fails on a broken providers config and registers
+// the local inventory and the priority list. The --inventory and --priority
+// flags override the file's settings. A broken declaration is fatal so it is
+// noticed instead of ignored.
+func loadConfiguredProviders() {
+	if providersCfgErr != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", providersCfgErr)
+		os.Exit(1)
+	}
+	inventory := inventoryPath
+	if inventory == "" {
+		inventory = providersCfg.Inventory
+	}
+	if inventory != "" {
+		if err := provider.RegisterInventory(inventory); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading inventory: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	priority := priorityList
+	if len(priority) == 0 {
+		priority = providersCfg.Priority
+	}
+	if len(priority) > 0 {
+		if err := provider.SetPriority(priority); err != nil {
+			fmt.Fprintf(os.Stderr, "Error in priority: %v\n", err)
+			os.Exit(1)
 		}
-		fmt.Printf("%-20s IP ranges updated successfully\n", colorizeProvider(p.Name))
 	}
 }
 
+func updateAllProviders() {
+	provider.UpdateAll(dataDir, func(name string, err error) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating %s: %v\n", name, err)
+			return
+		}
+		fmt.Printf("%-20s IP ranges updated successfully\n", colorizeProvider(name))
+	})
+}
+
 func scanIPs(ips []string) {
 	if !provider.HasAnyData(dataDir) {
 		fmt.Fprintln(os.Stderr, "Warning: no provider data found. Run 'ip-to-cloudprovider -a' to download IP ranges first.")
 	}
 
-	matcher := provider.NewMatcher(dataDir)
+	matcher := provider.NewMatcherFor(dataDir, selectedProviders())
 	results := matcher.MatchAll(ips)
 
+	var matched []string
+	for _, r := range results {
+		if r.Match {
+			matched = append(matched, r.Provider)
+		}
+	}
+	warnStale(matched)
+
 	var reports []reputation.Report
 	if checkRep {
 		reports = runReputation(ips)
 	}
 
+	var owners []assets.Attribution
+	if len(assetFiles) > 0 {
+		owners = attributeAssets(ips)
+	}
+
 	if jsonOutput {
-		if checkRep {
-			outputJSONWithReputation(results, reports)
+		if checkRep || owners != nil {
+			outputJSONCombined(results, reports, owners)
 		} else {
 			outputJSON(results)
 		}
 	} else {
-		if checkRep {
-			outputTextWithReputation(results, reports)
-		} else {
-			outputText(results)
-		}
+		outputText(results, reports, owners)
 		if showStats && len(results) > 1 {
 			outputStats(results)
 		}
 	}
 }
 
+// attributeAssets loads the exported cloud inventories and tells for each IP
+// whether it belongs to one of your accounts. A broken export is fatal, since
+// silently treating your own addresses as third party would be misleading.
+func attributeAssets(ips []string) []assets.Attribution {
+	inv, err := assets.LoadFiles(assetFiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading assets: %v\n", err)
+		os.Exit(1)
+	}
+
+	owners := make([]assets.Attribution, len(ips))
+	for i, ip := range ips {
+		owners[i] = inv.Attribute(ip)
+	}
+	return owners
+}
+
 // runReputation checks all IPs against the configured threat-intel sources.
 // Returns nil (and warns) if no sources are active or the config fails to load.
 func runReputation(ips []string) []reputation.Report {
@@ -299,16 +558,32 @@ func shodanScan(targets []string) {
 }
 
 func listProviders() {
+	providers := selectedProviders()
+
 	if jsonOutput {
 		type providerInfo struct {
-			Name    string `json:"name"`
-			HasData bool   `json:"has_data"`
+			Name        string `json:"name"`
+			DisplayName string `json:"display_name"`
+			Category    string `json:"category,omitempty"`
+			Color       string `json:"color,omitempty"`
+			Homepage    string `json:"homepage,omitempty"`
+			License     string `json:"license,omitempty"`
+			Expr        string `json:"expr,omitempty"`
+			HasData     bool   `json:"has_data"`
+			Stale       bool   `json:"stale,omitempty"`
 		}
 		var infos []providerInfo
-		for _, p := range provider.Registry {
+		for i, p := range providers {
 			infos = append(infos, providerInfo{
-				Name:    p.Name,
-				HasData: provider.HasData(p.Name, dataDir),
+				Name:        p.Name,
+				DisplayName: p.Title(),
+				Category:    p.Category,
+				Color:       p.Color,
+				Homepage:    p.Homepage,
+				License:     p.License,
+				Expr:        p.Expr,
+				HasData:     provider.HasData(p.Name, dataDir),
+				Stale:       provider.IsStale(&providers[i], dataDir),
 			})
 		}
 		enc := json.NewEncoder(os.Stdout)
@@ -317,16 +592,512 @@ func listProviders() {
 		return
 	}
 
-	fmt.Printf("%-20s %s\n", "PROVIDER", "STATUS")
-	fmt.Printf("%-20s %s\n", "--------", "------")
-	for _, p := range provider.Registry {
-		status := color.RedString("no data")
-		if provider.HasData(p.Name, dataDir) {
+	fmt.Printf("%-22s %-24s %-9s %s\n", "NAME", "PROVIDER", "CATEGORY", "STATUS")
+	fmt.Printf("%-22s %-24s %-9s %s\n", "----", "--------", "--------", "------")
+	for i, p := range providers {
+		var status string
+		switch {
+		case p.Load != nil:
+			status = color.GreenString("local") + " " + color.New(color.Faint).Sprint(p.URL)
+		case !provider.HasData(p.Name, dataDir):
+			status = color.RedString("no data")
+		case provider.IsStale(&providers[i], dataDir):
+			status = color.YellowString("stale") + " " + color.New(color.Faint).Sprintf("(older than %s, run '%s --update')", formatInterval(p.RefreshInterval), p.Name)
+		case p.Expr != "":
+			status = color.GreenString("ready") + " " + color.New(color.Faint).Sprintf("= %s", p.Expr)
+		default:
 			status = color.GreenString("ready")
 		}
-		fmt.Printf("%-20s %s\n", colorizeProvider(p.Name), status)
+		title := providerTitle(&p)
+		fmt.Printf("%-22s %s %-9s %s\n", p.Name, padColored(providerColor(&p).Sprint(title), title, 24), p.Category, status)
+	}
+	fmt.Printf("\n%d providers registered\n", len(providers))
+}
+
+// showOverlaps prints the shared address space of every pair of the selected
+// providers, or of the named ones.
+func showOverlaps(names []string) {
+	providers := namedProviders(names)
+	overlaps := provider.Overlaps(dataDir, providers)
+
+	if jsonOutput {
+		if overlaps == nil {
+			overlaps = []provider.Overlap{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(overlaps)
+		return
+	}
+
+	if len(overlaps) == 0 {
+		fmt.Printf("No overlaps between %d providers\n", len(providers))
+		return
+	}
+
+	// Matrix of the providers involved in at least one overlap.
+	shared := make(map[[2]string]provider.Overlap)
+	involved := make(map[string]bool)
+	for _, o := range overlaps {
+		shared[[2]string{o.A, o.B}] = o
+		shared[[2]string{o.B, o.A}] = o
+		involved[o.A], involved[o.B] = true, true
+	}
+	var rows []provider.Provider
+	for _, p := range providers {
+		if involved[p.Name] {
+			rows = append(rows, p)
+		}
+	}
+
+	fmt.Printf("%-26s", "")
+	for i := range rows {
+		fmt.Printf("%8d", i+1)
+	}
+	fmt.Println()
+	for i, row := range rows {
+		fmt.Printf("%3d %s", i+1, padColored(providerColor(&row).Sprint(row.Name), row.Name, 22))
+		for j, col := range rows {
+			cell := "."
+			if i == j {
+				cell = "-"
+			} else if o, ok := shared[[2]string{row.Name, col.Name}]; ok {
+				cell = overlapCell(o)
+			}
+			fmt.Printf("%8s", cell)
+		}
+		fmt.Println()
+	}
+	fmt.Println(color.New(color.Faint).Sprint("\nCells: shared IPv4 addresses; * also IPv6, v6 only IPv6"))
+
+	// Pairs, largest first.
+	slices.SortStableFunc(overlaps, func(a, b provider.Overlap) int {
+		if c := b.IPv4Addresses.Cmp(a.IPv4Addresses); c != 0 {
+			return c
+		}
+		return b.IPv6Addresses.Cmp(a.IPv6Addresses)
+	})
+	fmt.Println()
+	for _, o := range overlaps {
+		pair := o.A + " & " + o.B
+		fmt.Printf("  %-44s %5d prefixes  %8s IPv4  %8s IPv6\n",
+			pair, len(o.IPv4)+len(o.IPv6), formatCount(o.IPv4Addresses), formatCount(o.IPv6Addresses))
+	}
+}
+
+// showProviderStats prints dataset statistics of the selected providers, or
+// of the named ones.
+func showProviderStats(names []string, detail bool, top int) {
+	providers := namedProviders(names)
+
+	var all []*provider.Stats
+	union := &provider.IPRange{}
+	for _, p := range providers {
+		ipRange, err := provider.Load(p.Name, dataDir)
+		if err != nil {
+			continue
+		}
+		all = append(all, provider.RangeStats(p.Name, ipRange))
+		union.IPv4 = append(union.IPv4, ipRange.IPv4...)
+		union.IPv6 = append(union.IPv6, ipRange.IPv6...)
+	}
+	total := provider.RangeStats("total", union)
+
+	if jsonOutput {
+		if all == nil {
+			all = []*provider.Stats{}
+		}
+		// The union has no meaningful prefix extremes.
+		total.LargestIPv4, total.SmallestIPv4, total.LargestIPv6, total.SmallestIPv6 = "", "", "", ""
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(struct {
+			Providers []*provider.Stats `json:"providers"`
+			Total     *provider.Stats   `json:"total"`
+		}{all, total})
+		return
+	}
+
+	fmt.Printf("%-22s %7s %7s %9s %9s %9s %9s %9s\n", "NAME", "V4 PFX", "V6 PFX", "IPv4", "ROUTABLE", "IPv6", "V4 SIZES", "V6 SIZES")
+	fmt.Printf("%-22s %7s %7s %9s %9s %9s %9s %9s\n", "----", "------", "------", "----", "--------", "----", "--------", "--------")
+	for _, st := range all {
+		p := provider.ByName(st.Provider)
+		fmt.Printf("%s %7d %7d %9s %9s %9s %9s %9s\n",
+			padColored(providerColor(p).Sprint(st.Provider), st.Provider, 22),
+			st.IPv4Prefixes, st.IPv6Prefixes,
+			formatCount(st.IPv4Addresses), formatShare(st.RoutableShare), formatCount(st.IPv6Addresses),
+			prefixSizes(st.LargestIPv4, st.SmallestIPv4), prefixSizes(st.LargestIPv6, st.SmallestIPv6))
+	}
+	fmt.Printf("%-22s %7d %7d %9s %9s %9s\n", fmt.Sprintf("total (%d providers)", len(all)),
+		total.IPv4Prefixes, total.IPv6Prefixes,
+		formatCount(total.IPv4Addresses), formatShare(total.RoutableShare), formatCount(total.IPv6Addresses))
+
+	if !detail {
+		return
+	}
+	for _, st := range all {
+		fmt.Printf("\n%s\n", headerColor.Sprintf("=== %s ===", colorizeProvider(st.Provider)))
+		if st.LargestIPv4 != "" {
+			fmt.Printf("  %-16s largest %-20s smallest %s\n", "IPv4 prefixes:", st.LargestIPv4, st.SmallestIPv4)
+		}
+		if st.LargestIPv6 != "" {
+			fmt.Printf("  %-16s largest %-20s smallest %s\n", "IPv6 prefixes:", st.LargestIPv6, st.SmallestIPv6)
+		}
+		printBreakdowns("Regions", st.Regions, top)
+		printBreakdowns("Services", st.Services, top)
+	}
+}
+
+// exportOptions holds the flags of the export command.
+type exportOptions struct {
+	format     string
+	action     string
+	name       string
+	output     string
+	aggregate  bool
+	ipv4Only   bool
+	ipv6Only   bool
+	maxEntries int
+}
+
+// exportProviders renders the ranges of the selected providers, or of the
+// named ones, to stdout or to the --output file.
+func exportProviders(names []string, opts exportOptions) {
+	list, err := buildExportList(names, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	warnStale(list.Sources)
+
+	// Render fully before writing so a failure never leaves a partial file.
+	var buf bytes.Buffer
+	if err := export.Render(&buf, opts.format, list, export.Options{Action: opts.action, MaxEntries: opts.maxEntries}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if opts.output == "" {
+		_, _ = buf.WriteTo(os.Stdout)
+		return
+	}
+	if err := os.WriteFile(opts.output, buf.Bytes(), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", opts.output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %d IPv4 and %d IPv6 prefixes of %s to %s\n",
+		len(list.IPv4), len(list.IPv6), strings.Join(list.Sources, ", "), opts.output)
+}
+
+// buildExportList merges the stored ranges of the providers into one list.
+// Without aggregation the providers' own prefixes are kept, minus exact
+// duplicates, and each carries the provider and metadata it was published
+// with; a prefix published by several providers is attributed to the one
+// ranked first in the priority list. Either way the list is sorted by
+// address, so repeated exports of the same data are identical.
+func buildExportList(names []string, opts exportOptions) (export.List, error) {
+	format := export.ByName(opts.format)
+	if format != nil && format.PerPrefix && opts.aggregate {
+		return export.List{}, fmt.Errorf("%s keeps per-prefix details and cannot be combined with --aggregate", opts.format)
+	}
+
+	var prefixes []netip.Prefix
+	var sources []string
+	loaded := make(map[string]*provider.IPRange)
+	providers := namedProviders(names)
+	for _, p := range providers {
+		ipRange, err := provider.Load(p.Name, dataDir)
+		if err != nil {
+			if len(names) > 0 {
+				return export.List{}, fmt.Errorf("loading %s: %w", p.Name, err)
+			}
+			continue
+		}
+		prefixes = append(prefixes, ipRange.Prefixes()...)
+		sources = append(sources, p.Name)
+		loaded[p.Name] = ipRange
+	}
+
+	var attrs map[netip.Prefix]export.Attr
+	if opts.aggregate {
+		prefixes = cidr.Aggregate(prefixes)
+	} else {
+		slices.SortFunc(prefixes, func(a, b netip.Prefix) int {
+			if c := a.Addr().Compare(b.Addr()); c != 0 {
+				return c
+			}
+			return a.Bits() - b.Bits()
+		})
+		prefixes = slices.Compact(prefixes)
+		attrs = exportAttrs(providers, loaded)
+	}
+
+	name := opts.name
+	switch {
+	case name != "":
+	case len(names) > 0:
+		name = strings.Join(sources, "-")
+	case len(categoryFilter) > 0:
+		name = strings.Join(categoryFilter, "-")
+	default:
+		name = "all"
+	}
+	list := export.NewList(name, sources, prefixes)
+	list.Attrs = attrs
+	if opts.ipv4Only {
+		list.IPv6 = nil
+	}
+	if opts.ipv6Only {
+		list.IPv4 = nil
+	}
+	if len(list.IPv4)+len(list.IPv6) == 0 {
+		return export.List{}, fmt.Errorf("no prefixes to export")
+	}
+	return list, nil
+}
+
+// exportAttrs attributes every loaded prefix to its provider, visiting the
+// providers in priority order so the first one ranked keeps a shared prefix.
+func exportAttrs(providers []provider.Provider, loaded map[string]*provider.IPRange) map[netip.Prefix]export.Attr {
+	attrs := make(map[netip.Prefix]export.Attr)
+	for _, p := range provider.SortByPriority(providers) {
+		ipRange := loaded[p.Name]
+		if ipRange == nil {
+			continue
+		}
+		for _, cidrs := range [][]string{ipRange.IPv4, ipRange.IPv6} {
+			for _, c := range cidrs {
+				prefix, err := cidr.ParsePrefix(c)
+				if err != nil {
+					continue
+				}
+				if _, ok := attrs[prefix]; ok {
+					continue
+				}
+				attrs[prefix] = export.Attr{
+					Provider:    p.Name,
+					DisplayName: providerTitle(&p),
+					Category:    p.Category,
+					Meta:        ipRange.MetaFor(c),
+				}
+			}
+		}
+	}
+	return attrs
+}
+
+// allowlistOptions holds the flags of the allowlist command.
+type allowlistOptions struct {
+	ipv4Only bool
+	ipv6Only bool
+	output   string
+	verify   string
+}
+
+// printAllowlist prints the aggregated allowlist of the named providers, or
+// with --verify only checks its checksum.
+func printAllowlist(names []string, opts allowlistOptions) {
+	family := 0
+	if opts.ipv4Only {
+		family = 4
+	}
+	if opts.ipv6Only {
+		family = 6
+	}
+	list, err := provider.BuildAllowlist(names, family, dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if opts.verify != "" {
+		if !strings.EqualFold(strings.TrimSpace(opts.verify), list.SHA256) {
+			fmt.Fprintf(os.Stderr, "Allowlist changed: expected sha256 %s, got %s\n", opts.verify, list.SHA256)
+			os.Exit(1)
+		}
+		fmt.Printf("Allowlist unchanged (sha256 %s)\n", list.SHA256)
+		return
+	}
+
+	var buf bytes.Buffer
+	if jsonOutput {
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(list)
+	} else {
+		writeAllowlist(&buf, list)
+	}
+	if opts.output == "" {
+		_, _ = buf.WriteTo(os.Stdout)
+		return
+	}
+	if err := os.WriteFile(opts.output, buf.Bytes(), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", opts.output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %d prefixes (sha256 %s) to %s\n", len(list.IPv4)+len(list.IPv6), list.SHA256, opts.output)
+}
+
+// writeAllowlist writes the plain-text list: comment lines, then one prefix
+// per line. The checksum covers the prefix lines only, so it can be
+// recomputed with: grep -v '^#' <file> | sha256sum
+func writeAllowlist(w io.Writer, list *provider.Allowlist) {
+	fmt.Fprintf(w, "# allowlist for %s\n", strings.Join(list.Providers, ", "))
+	fmt.Fprintf(w, "# %d prefixes (%d IPv4, %d IPv6), aggregated from %d\n",
+		len(list.IPv4)+len(list.IPv6), len(list.IPv4), len(list.IPv6), list.SourcePrefixes)
+	fmt.Fprintf(w, "# sha256 %s\n", list.SHA256)
+	for _, line := range list.Lines() {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// exportFormatHelp lists the export formats for the command's help text.
+func exportFormatHelp() string {
+	var b strings.Builder
+	for i, f := range export.Formats() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "  %-10s %s", f.Name, f.Description)
+	}
+	return b.String()
+}
+
+// printBreakdowns prints up to top entries of a breakdown (all when top is 0).
+func printBreakdowns(title string, entries []provider.Breakdown, top int) {
+	if len(entries) == 0 {
+		return
+	}
+	shown := entries
+	if top > 0 && len(shown) > top {
+		shown = shown[:top]
+	}
+	if len(shown) < len(entries) {
+		fmt.Printf("  %s (top %d of %d):\n", title, len(shown), len(entries))
+	} else {
+		fmt.Printf("  %s:\n", title)
+	}
+	for _, b := range shown {
+		fmt.Printf("    %-28s %6d prefixes  %8s IPv4  %8s IPv6\n", b.Name, b.Prefixes, formatCount(b.IPv4Addresses), formatCount(b.IPv6Addresses))
+	}
+}
+
+// prefixSizes renders the largest and smallest prefix lengths, e.g. "/9-/32".
+func prefixSizes(largest, smallest string) string {
+	if largest == "" {
+		return "-"
+	}
+	_, l, _ := strings.Cut(largest, "/")
+	_, s, _ := strings.Cut(smallest, "/")
+	if l == s {
+		return "/" + l
+	}
+	return "/" + l + "-/" + s
+}
+
+// formatShare renders a fraction as a percentage with enough precision for
+// the tiny shares most providers hold.
+func formatShare(f float64) string {
+	switch {
+	case f == 0:
+		return "0%"
+	case f < 0.0001:
+		return "<0.01%"
+	}
+	return fmt.Sprintf("%.2f%%", f*100)
+}
+
+// overlapCell renders one matrix cell: the shared IPv4 address count, marked
+// when IPv6 space is shared too.
+func overlapCell(o provider.Overlap) string {
+	switch {
+	case o.IPv4Addresses.Sign() == 0:
+		return "v6"
+	case o.IPv6Addresses.Sign() > 0:
+		return formatCount(o.IPv4Addresses) + "*"
+	}
+	return formatCount(o.IPv4Addresses)
+}
+
+// formatCount renders an address count compactly: exact below 10,000, with
+// a k/M/G suffix up to IPv4 sizes, and as a power of two beyond that, which is
+// how IPv6 space is usually discussed.
+func formatCount(n *big.Int) string {
+	switch {
+	case n.Cmp(big.NewInt(10_000)) < 0:
+		return n.String()
+	case n.BitLen() > 40:
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return fmt.Sprintf("2^%.1f", math.Log2(f))
+	}
+	v := float64(n.Int64())
+	for _, unit := range []string{"k", "M", "G"} {
+		v /= 1000
+		if v < 1000 || unit == "G" {
+			return fmt.Sprintf("%.1f%s", v, unit)
+		}
+	}
+	return n.String()
+}
+
+// namedProviders returns the selected providers with the given names, in that
+// order, or all selected providers when no names are given. An unknown name
+// is fatal.
+func namedProviders(names []string) []provider.Provider {
+	providers := selectedProviders()
+	if len(names) == 0 {
+		return providers
+	}
+	var named []provider.Provider
+	for _, name := range names {
+		i := slices.IndexFunc(providers, func(p provider.Provider) bool { return p.Name == name })
+		if i < 0 {
+			fmt.Fprintf(os.Stderr, "Error: unknown provider %q\n", name)
+			os.Exit(1)
+		}
+		named = append(named, providers[i])
+	}
+	return named
+}
+
+// selectedProviders returns the providers in the categories given with
+// --category, or all of them. An unknown category is fatal, since a typo
+// would otherwise silently match nothing.
+func selectedProviders() []provider.Provider {
+	known := provider.Categories()
+	for _, c := range categoryFilter {
+		if !slices.Contains(known, c) {
+			fmt.Fprintf(os.Stderr, "Error: unknown category %q (known: %s)\n", c, strings.Join(known, ", "))
+			os.Exit(1)
+		}
+	}
+	return provider.ByCategory(categoryFilter...)
+}
+
+// warnStale tells on stderr which of the named providers have data older than
+// their RefreshInterval, since a stale exit list silently misses new nodes.
+func warnStale(names []string) {
+	var warned []string
+	for _, name := range names {
+		p := provider.ByName(name)
+		if p == nil || slices.Contains(warned, name) || !provider.IsStale(p, dataDir) {
+			continue
+		}
+		warned = append(warned, name)
+		fmt.Fprintf(os.Stderr, "Warning: %s data is older than %s. Run 'ip-to-cloudprovider %s --update' for current ranges.\n",
+			name, formatInterval(p.RefreshInterval), name)
+	}
+}
+
+// formatInterval renders a refresh interval compactly, e.g. "1h" or "30m".
+func formatInterval(d time.Duration) string {
+	out := d.String()
+	if strings.HasSuffix(out, "m0s") {
+		out = strings.TrimSuffix(out, "0s")
+	}
+	if strings.HasSuffix(out, "h0m") {
+		out = strings.TrimSuffix(out, "0m")
 	}
-	fmt.Printf("\n%d providers registered\n", len(provider.Registry))
+	return out
 }
 
 // ---------------------------------------------------------------------------
@@ -411,14 +1182,18 @@ func outputJSON(results []provider.MatchResult) {
 	}
 }
 
-// combinedResult merges a provider match with its reputation report for JSON output.
+// combinedResult merges a provider match with its reputation report and
+// account ownership for JSON output.
 type combinedResult struct {
 	provider.MatchResult
-	Reputation *reputation.Report `json:"reputation,omitempty"`
+	Reputation *reputation.Report  `json:"reputation,omitempty"`
+	Ownership  *assets.Attribution `json:"ownership,omitempty"`
 }
 
-// outputJSONWithReputation emits the provider match and reputation report per IP.
-func outputJSONWithReputation(results []provider.MatchResult, reports []reputation.Report) {
+// outputJSONCombined emits the provider match plus the reputation report and
+// ownership per IP. reports and owners are aligned by index with results and
+// may be nil.
+func outputJSONCombined(results []provider.MatchResult, reports []reputation.Report, owners []assets.Attribution) {
 	combined := make([]combinedResult, len(results))
 	for i, r := range results {
 		combined[i] = combinedResult{MatchResult: r}
@@ -426,6 +1201,10 @@ func outputJSONWithReputation(results []provider.MatchResult, reports []reputati
 			rep := reports[i]
 			combined[i].Reputation = &rep
 		}
+		if i < len(owners) {
+			owner := owners[i]
+			combined[i].Ownership = &owner
+		}
 	}
 
 	enc := json.NewEncoder(os.Stdout)
@@ -558,28 +1337,22 @@ func colorizeService(s shodan.Service) string {
 	return out
 }
 
-func outputText(results []provider.MatchResult) {
-	for _, r := range results {
-		ip := padColored(colorizeIP(r.IP), r.IP, 20)
-		if r.Match {
-			fmt.Printf("%s is in the range of %s\n", ip, colorizeProvider(r.Provider))
-		} else {
-			fmt.Printf("%s %s\n", ip, color.New(color.Faint).Sprint("is not in the range of any provider"))
-		}
-	}
-}
-
-// outputTextWithReputation prints the provider match plus a reputation verdict
-// for each IP. reports is aligned by index with results.
-func outputTextWithReputation(results []provider.MatchResult, reports []reputation.Report) {
+// outputText prints the provider match for each IP, followed by its ownership
+// and reputation verdict when available. reports and owners are aligned by
+// index with results and may be nil.
+func outputText(results []provider.MatchResult, reports []reputation.Report, owners []assets.Attribution) {
 	for i, r := range results {
 		ip := padColored(colorizeIP(r.IP), r.IP, 20)
 		if r.Match {
-			fmt.Printf("%s is in the range of %s", ip, colorizeProvider(r.Provider))
+			fmt.Printf("%s is in the range of %s%s", ip, colorizeProvider(r.Provider), describeMeta(r.Meta))
 		} else {
 			fmt.Printf("%s %s", ip, color.New(color.Faint).Sprint("is not in the range of any provider"))
 		}
 
+		if i < len(owners) {
+			fmt.Print(describeOwnership(r, owners[i]))
+		}
+
 		if i < len(reports) {
 			rep := reports[i]
 			fmt.Printf("  [%s]", colorizeVerdict(rep.Verdict, rep.Score))
@@ -591,6 +1364,92 @@ func outputTextWithReputation(results []provider.MatchResult, reports []reputati
 	}
 }
 
+// describeOwnership renders ", owned by account 1234 / eni-…" for your own
+// addresses and ", third party" for provider matches that are not yours.
+func describeOwnership(r provider.MatchResult, owner assets.Attribution) string {
+	if !owner.Owned {
+		if !r.Match {
+			return ""
+		}
+		return color.New(color.Faint).Sprint(", third party")
+	}
+
+	owned := "owned by account " + owner.Asset.Account
+	if owner.Asset.Account == "" {
+		owned = "owned"
+	}
+	if owner.Asset.Resource != "" {
+		owned += " / " + owner.Asset.Resource
+	}
+	return ", " + color.New(color.FgGreen, color.Bold).Sprint(owned)
+}
+
+// describeMeta renders the published details of a matching prefix (region or
+// location, tags) or the inventory details of a local prefix ("corp-vpn /
+// team-x") as a faint suffix, or an empty string when there are none.
+func describeMeta(meta *provider.PrefixMeta) string {
+	if meta == nil {
+		return ""
+	}
+	var parts []string
+	if owner := formatInventoryOwner(meta); owner != "" {
+		parts = append(parts, owner)
+	}
+	if meta.Environment != "" {
+		parts = append(parts, meta.Environment)
+	}
+	if loc := formatPrefixLocation(meta); loc != "" {
+		parts = append(parts, loc)
+	}
+	if meta.ASN != 0 {
+		parts = append(parts, fmt.Sprintf("AS%d", meta.ASN))
+	}
+	if len(meta.Tags) > 0 {
+		parts = append(parts, strings.Join(meta.Tags, ", "))
+	}
+	if len(meta.Labels) > 0 {
+		parts = append(parts, strings.Join(provider.SortedLabels(meta.Labels), ", "))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " " + color.New(color.Faint).Sprintf("(%s)", strings.Join(parts, "; "))
+}
+
+// formatInventoryOwner renders an inventory prefix as "name / owner", or
+// whichever of the two is set.
+func formatInventoryOwner(meta *provider.PrefixMeta) string {
+	switch {
+	case meta.Name != "" && meta.Owner != "":
+		return meta.Name + " / " + meta.Owner
+	case meta.Name != "":
+		return meta.Name
+	}
+	return meta.Owner
+}
+
+// formatPrefixLocation renders a prefix's geofeed location as "City, CC",
+// falling back to the region (e.g. a cloud region name) when no country is set.
+func formatPrefixLocation(meta *provider.PrefixMeta) string {
+	if meta.Country == "" {
+		if meta.City != "" && meta.Region != "" {
+			return meta.City + ", " + meta.Region
+		}
+		if meta.City != "" {
+			return meta.City
+		}
+		return meta.Region
+	}
+	place := meta.City
+	if place == "" {
+		place = meta.Region
+	}
+	if place == "" {
+		return meta.Country
+	}
+	return place + ", " + meta.Country
+}
+
 // listedSources returns a comma-separated list of sources that flagged the IP.
 func listedSources(rep reputation.Report) string {
 	var names []string
@@ -707,35 +1566,91 @@ func colorizeTarget(s string) string {
 	return colorizeURL(s)
 }
 
+// colorizeProvider renders a provider's display name in its color. Providers
+// without a color of their own get their category's color, so new providers
+// stand out without touching this file.
 func colorizeProvider(name string) string {
-	var c *color.Color
+	p := provider.ByName(name)
+	if p == nil {
+		return color.New(color.FgWhite).Sprint(capitalizeFirst(name))
+	}
+	return providerColor(p).Sprint(providerTitle(p))
+}
 
-	switch strings.ToLower(name) {
-	case "microsoft":
-		c = color.New(color.FgBlue, color.Bold)
-	case "github", "githubactions", "githubhooks", "githubpages":
-		c = color.New(color.FgBlack, color.BgWhite, color.Bold)
-	case "amazon":
-		c = color.New(color.FgYellow, color.Bold)
-	case "cloudflare":
-		c = color.New(color.FgHiRed, color.BgYellow, color.Bold)
-	case "google", "googlecloud", "googlebot":
-		c = color.New(color.FgRed, color.Bold)
-	case "openai":
-		c = color.New(color.FgCyan, color.Bold)
-	case "digitalocean":
-		c = color.New(color.FgBlue, color.Bold)
-	case "alibaba":
-		c = color.New(color.FgHiYellow, color.Bold)
-	case "anthropic":
-		c = color.New(color.FgHiMagenta, color.Bold)
-	case "hetzner":
-		c = color.New(color.FgRed, color.Bold)
-	default:
-		c = color.New(color.FgWhite)
+// providerTitle returns the display name, or the capitalized provider name
+// when none is declared.
+func providerTitle(p *provider.Provider) string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+	return capitalizeFirst(p.Name)
+}
+
+// categoryColors is the fallback color of each category.
+var categoryColors = map[string]string{
+	provider.CategoryCloud: "blue",
+	provider.CategoryCDN:   "yellow",
+	provider.CategoryBot:   "cyan",
+	provider.CategoryCI:    "white",
+	provider.CategoryProxy: "magenta",
+	provider.CategoryAI:    "hi-magenta",
+	provider.CategoryLocal: "hi-green",
+}
+
+func providerColor(p *provider.Provider) *color.Color {
+	if c := namedColor(p.Color); c != nil {
+		return c
+	}
+	if c := namedColor(categoryColors[p.Category]); c != nil {
+		return c
 	}
+	return color.New(color.FgWhite)
+}
 
-	return c.Sprint(capitalizeFirst(name))
+// namedColors maps the color names accepted in provider declarations to
+// foreground attributes. A "hi-" prefix selects the bright variant.
+var namedColors = map[string][2]color.Attribute{
+	"black":   {color.FgBlack, color.FgHiBlack},
+	"red":     {color.FgRed, color.FgHiRed},
+	"green":   {color.FgGreen, color.FgHiGreen},
+	"yellow":  {color.FgYellow, color.FgHiYellow},
+	"blue":    {color.FgBlue, color.FgHiBlue},
+	"magenta": {color.FgMagenta, color.FgHiMagenta},
+	"cyan":    {color.FgCyan, color.FgHiCyan},
+	"white":   {color.FgWhite, color.FgHiWhite},
+}
+
+// namedColor returns a bold color for a name like "red", "hi-magenta" or
+// "black on white" (foreground on background), or nil if the name is unknown.
+func namedColor(name string) *color.Color {
+	fgName, bgName, hasBg := strings.Cut(strings.ToLower(strings.TrimSpace(name)), " on ")
+	fg, ok := colorAttribute(fgName)
+	if !ok {
+		return nil
+	}
+	c := color.New(fg, color.Bold)
+	if hasBg {
+		bg, ok := colorAttribute(bgName)
+		if !ok {
+			return nil
+		}
+		c.Add(bg + (color.BgBlack - color.FgBlack))
+	}
+	return c
+}
+
+// colorAttribute returns the foreground attribute for a single color name.
+func colorAttribute(name string) (color.Attribute, bool) {
+	name = strings.TrimSpace(name)
+	bright := 0
+	if rest, ok := strings.CutPrefix(name, "hi-"); ok {
+		name, bright = rest, 1
+	}
+	attrs, ok := namedColors[name]
+	if !ok {
+		return 0, false
+	}
+	return attrs[bright], true
 }
 
 func capitalizeFirst(s string) string {
@@ -4,14 +4,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/BenjiTrapp/ip-to-cloudprovider/cidr"
 	"github.com/BenjiTrapp/ip-to-cloudprovider/provider"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -21,21 +26,38 @@ import (
 // ---------------------------------------------------------------------------
 
 var mockProviderData = map[string]string{
-	"amazon":        `{"prefixes": [{"ip_prefix": "13.224.0.0/14"}, {"ip_prefix": "52.94.76.0/22"}], "ipv6_prefixes": [{"ipv6_prefix": "2600:1f00::/24"}]}`,
-	"cloudflare":    `{"result": {"ipv4_cidrs": ["198.41.128.0/17", "104.16.0.0/13"], "ipv6_cidrs": ["2400:cb00::/32"]}}`,
-	"github":        `{"web": ["192.30.252.0/22"], "actions": ["4.148.0.0/15"], "hooks": ["140.82.112.0/20"], "pages": ["185.199.108.0/22"]}`,
-	"githubactions": `{"web": ["192.30.252.0/22"], "actions": ["4.148.0.0/15"], "hooks": ["140.82.112.0/20"], "pages": ["185.199.108.0/22"]}`,
-	"githubhooks":   `{"web": ["192.30.252.0/22"], "actions": ["4.148.0.0/15"], "hooks": ["140.82.112.0/20"], "pages": ["185.199.108.0/22"]}`,
-	"githubpages":   `{"web": ["192.30.252.0/22"], "actions": ["4.148.0.0/15"], "hooks": ["140.82.112.0/20"], "pages": ["185.199.108.0/22"]}`,
-	"google":        "8.8.8.0/24\n8.8.4.0/24\n2001:4860::/32\n",
-	"googlecloud":   `{"prefixes": [{"ipv4Prefix": "34.80.0.0/15"}, {"ipv6Prefix": "2600:1900::/35"}]}`,
-	"googlebot":     `{"prefixes": [{"ipv4Prefix": "66.249.64.0/19"}]}`,
-	"openai":        "23.98.142.176/28\n40.84.180.224/28\n",
-	"digitalocean":  "64.225.84.0/22,IN,IN-KA,Bangalore,560100\n142.93.0.0/16,US,US-NJ,North Bergen,07047\n2400:6180:0:d0::/64,SG,SG-05,Singapore,627753\n",
-	"alibaba":       "# AS45102\n8.208.0.0/16\n47.52.0.0/16\n2400:3200::/48\n",
-	"anthropic":     "Inbound IPv4\n160.79.104.0/23\nIPv6\n2607:6bc0::/48\nOutbound IPv4\n160.79.104.0/21\n",
-	"hetzner":       "# AS24940\n5.9.0.0/16\n49.12.0.0/15\n95.216.0.0/15\n2a01:4f8::/31\n",
-	"microsoft":     "20.0.0.0/8\n2603:1000::/24\n",
+	"amazon":              `{"prefixes": [{"ip_prefix": "13.224.0.0/14"}, {"ip_prefix": "52.94.76.0/22"}, {"ip_prefix": "18.160.0.0/15", "service": "AMAZON"}, {"ip_prefix": "18.160.0.0/15", "service": "CLOUDFRONT"}], "ipv6_prefixes": [{"ipv6_prefix": "2600:1f00::/24"}]}`,
+	"cloudflare":          `{"result": {"ipv4_cidrs": ["198.41.128.0/17", "104.16.0.0/13"], "ipv6_cidrs": ["2400:cb00::/32"]}}`,
+	"github":              `{"web": ["192.30.252.0/22"], "actions": ["4.148.0.0/15"], "hooks": ["140.82.112.0/20"], "pages": ["185.199.108.0/22"]}`,
+	"githubactions":       `{"web": ["192.30.252.0/22"], "actions": ["4.148.0.0/15"], "hooks": ["140.82.112.0/20"], "pages": ["185.199.108.0/22"]}`,
+	"githubhooks":         `{"web": ["192.30.252.0/22"], "actions": ["4.148.0.0/15"], "hooks": ["140.82.112.0/20"], "pages": ["185.199.108.0/22"]}`,
+	"githubpages":         `{"web": ["192.30.252.0/22"], "actions": ["4.148.0.0/15"], "hooks": ["140.82.112.0/20"], "pages": ["185.199.108.0/22"]}`,
+	"google":              "8.8.8.0/24\n8.8.4.0/24\n2001:4860::/32\n",
+	"googlecloud":         `{"prefixes": [{"ipv4Prefix": "34.80.0.0/15"}, {"ipv6Prefix": "2600:1900::/35"}]}`,
+	"googlebot":           `{"prefixes": [{"ipv4Prefix": "66.249.64.0/19"}]}`,
+	"openai":              "23.98.142.176/28\n40.84.180.224/28\n",
+	"digitalocean":        "64.225.84.0/22,IN,IN-KA,Bangalore,560100\n142.93.0.0/16,US,US-NJ,North Bergen,07047\n2400:6180:0:d0::/64,SG,SG-05,Singapore,627753\n",
+	"alibaba":             "# AS45102\n8.208.0.0/16\n47.52.0.0/16\n2400:3200::/48\n",
+	"anthropic":           "<h2>Inbound IP addresses</h2><pre><code>160.79.104.0/23</code></pre><pre><code>2607:6bc0::/48</code></pre><h2>Outbound IP addresses</h2><pre><code>160.79.104.0/21</code></pre>",
+	"hetzner":             "# AS24940\n5.9.0.0/16\n49.12.0.0/15\n95.216.0.0/15\n2a01:4f8::/31\n",
+	"microsoft":           "20.0.0.0/8\n2603:1000::/24\n",
+	"linode":              "# Linode geofeed\n139.162.0.0/21,NL,NL-NH,Amsterdam,\n2600:3c00::/48,US,US-TX,Richardson,\n",
+	"vultr":               "45.32.0.0/19,US,US-NJ,Piscataway,\n",
+	"oracle":              `{"regions": [{"region": "us-phoenix-1", "cidrs": [{"cidr": "129.146.0.0/21", "tags": ["OCI"]}]}]}`,
+	"bingbot":             `{"prefixes": [{"ipv4Prefix": "157.55.39.0/24"}, {"ipv4Prefix": "207.46.13.0/24"}]}`,
+	"applebot":            `{"prefixes": [{"ipv4Prefix": "17.241.208.0/22"}, {"ipv6Prefix": "2a01:b740:a16::/48"}]}`,
+	"duckduckbot":         `{"prefixes": [{"ipv4Prefix": "20.191.45.212/32"}]}`,
+	"perplexitybot":       `{"prefixes": [{"ipv4Prefix": "107.20.236.150/32"}]}`,
+	"perplexity-user":     `{"prefixes": [{"ipv4Prefix": "44.208.221.197/32"}]}`,
+	"commoncrawl":         `{"prefixes": [{"ipv4Prefix": "18.97.9.168/29"}, {"ipv6Prefix": "2600:1f28:365:80b0::/60"}]}`,
+	"fastly":              `{"addresses": ["151.101.0.0/16", "199.232.0.0/16"], "ipv6_addresses": ["2a04:4e40::/32"]}`,
+	"akamai":              "# AS20940\n23.32.0.0/11\n2a02:26f0::/29\n",
+	"bunnycdn":            `["89.187.188.227", "185.152.64.17", "2400:52e0:1a00::1"]`,
+	"tor":                 "# exit list\n185.220.101.1\n185.220.101.2\n",
+	"apple-private-relay": "172.224.226.0/27,GB,GB-EN,London,\n2a02:26f7:b3c0:4000::/64,GB,GB-EN,London,\n",
+	"cloudflare-warp":     "104.28.0.0/24,US,US-CA,San Jose,\n",
+	"zscaler":             `{"zscaler.net": {"continent : EMEA": {"city : Amsterdam II": [{"range": "165.225.240.0/23"}]}}}`,
+	"m365":                `[{"id": 1, "serviceArea": "Exchange", "category": "Optimize", "ips": ["13.107.6.152/31", "2603:1006::/40"]}]`,
 }
 
 func createMockServer() *httptest.Server {
@@ -52,6 +74,14 @@ func createMockServer() *httptest.Server {
 func setupTestData(t *testing.T, dir string) {
 	t.Helper()
 	for name, data := range mockProviderData {
+		if s := provider.SourceByName(name); s != nil && s.Split != nil {
+			ranges, err := s.Split([]byte(data))
+			require.NoError(t, err, "failed to split mock data for source %s", name)
+			for member, ipRange := range ranges {
+				require.NoError(t, provider.Save(member, ipRange, dir), "failed to save mock data for %s", member)
+			}
+			continue
+		}
 		p := provider.ByName(name)
 		if p == nil {
 			continue
@@ -110,6 +140,17 @@ func captureOutput(f func()) string {
 	return string(out)
 }
 
+func captureStderr(f func()) string {
+	old := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	f()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stderr = old
+	return string(out)
+}
+
 func createTempIPFile(t *testing.T, lines []string) string {
 	t.Helper()
 	tmpFile, err := os.CreateTemp("", "test_ips_*.txt")
@@ -155,10 +196,20 @@ func TestScanIPs_TextOutput(t *testing.T) {
 		{"single Amazon IP", []string{"13.224.1.1"}, []string{"13.224.1.1", "Amazon"}},
 		{"multiple IPs", []string{"13.224.1.1", "198.41.200.1", "1.2.3.4"}, []string{"Amazon", "Cloudflare", "not in the range"}},
 		{"IPv6 lookup", []string{"2400:cb00::1"}, []string{"Cloudflare"}},
-		{"DigitalOcean", []string{"64.225.84.1"}, []string{"Digitalocean"}},
+		{"DigitalOcean", []string{"64.225.84.1"}, []string{"DigitalOcean", "Bangalore, IN"}},
 		{"Alibaba Cloud", []string{"8.208.1.1"}, []string{"Alibaba"}},
 		{"Anthropic", []string{"160.79.104.1"}, []string{"Anthropic"}},
 		{"Hetzner", []string{"49.12.1.1"}, []string{"Hetzner"}},
+		{"Oracle with region", []string{"129.146.1.1"}, []string{"Oracle", "us-phoenix-1", "OCI"}},
+		{"Bingbot", []string{"157.55.39.10"}, []string{"Bingbot"}},
+		{"Fastly", []string{"151.101.1.1"}, []string{"Fastly"}},
+		{"Tor exit", []string{"185.220.101.2"}, []string{"Tor"}},
+		{"iCloud Private Relay", []string{"172.224.226.1"}, []string{"iCloud Private Relay", "London, GB"}},
+		{"Microsoft 365", []string{"13.107.6.153"}, []string{"Microsoft 365", "Exchange/optimize"}},
+		{"Zscaler", []string{"165.225.241.1"}, []string{"Zscaler", "Amsterdam II"}},
+		{"CloudFront before Amazon", []string{"18.160.1.1"}, []string{"Amazon CloudFront"}},
+		{"Bunny CDN edge host", []string{"185.152.64.17"}, []string{"Bunny CDN"}},
+		{"Google", []string{"8.8.8.8"}, []string{"range of Google\n"}},
 	}
 
 	for _, tc := range tests {
@@ -193,6 +244,111 @@ func TestScanIPs_JSONOutput(t *testing.T) {
 	assert.False(t, results[2].Match)
 }
 
+func TestScanIPs_CategoryFilter(t *testing.T) {
+	dir := t.TempDir()
+	setupTestData(t, dir)
+	defer withDataDir(t, dir)()
+	defer func() { categoryFilter = nil }()
+	jsonOutput = true
+
+	categoryFilter = []string{provider.CategoryBot}
+	output := captureOutput(func() { scanIPs([]string{"13.224.1.1", "157.55.39.10"}) })
+
+	var results []provider.MatchResult
+	require.NoError(t, json.Unmarshal([]byte(output), &results))
+	require.Len(t, results, 2)
+	assert.False(t, results[0].Match, "amazon is not a bot")
+	assert.Equal(t, "bingbot", results[1].Provider)
+	assert.Equal(t, "Bingbot", results[1].DisplayName)
+	assert.Equal(t, provider.CategoryBot, results[1].Category)
+}
+
+func TestScanIPs_Priority(t *testing.T) {
+	dir := t.TempDir()
+	setupTestData(t, dir)
+	defer withDataDir(t, dir)()
+	defer func() { provider.Priority = provider.DefaultPriority }()
+	jsonOutput = true
+
+	scan := func() provider.MatchResult {
+		var results []provider.MatchResult
+		output := captureOutput(func() { scanIPs([]string{"18.160.1.1"}) })
+		require.NoError(t, json.Unmarshal([]byte(output), &results))
+		require.Len(t, results, 1)
+		return results[0]
+	}
+
+	assert.Equal(t, "cloudfront", scan().Provider, "CloudFront ranks above Amazon by default")
+
+	require.NoError(t, provider.SetPriority([]string{"amazon"}))
+	assert.Equal(t, "amazon", scan().Provider)
+
+	jsonOutput = false
+	showStats = true
+	defer func() { showStats = false }()
+	output := captureOutput(func() { scanIPs([]string{"18.160.1.1", "18.160.1.2"}) })
+	assert.Contains(t, output, "Amazon AWS:")
+	assert.NotContains(t, output, "CloudFront")
+}
+
+func TestScanIPs_Inventory(t *testing.T) {
+	dir := t.TempDir()
+	setupTestData(t, dir)
+	defer withDataDir(t, dir)()
+
+	orig := provider.Registry
+	t.Cleanup(func() { provider.Registry = orig })
+	inventory := filepath.Join(t.TempDir(), "inventory.csv")
+	require.NoError(t, os.WriteFile(inventory, []byte("cidr,name,owner,environment,labels\n13.224.1.0/24,corp-vpn,team-x,prod,site=fra1\n"), 0644))
+	require.NoError(t, provider.RegisterInventory(inventory))
+
+	jsonOutput = false
+	output := captureOutput(func() { scanIPs([]string{"13.224.1.1", "13.224.2.1"}) })
+	assert.Contains(t, output, "corp-vpn / team-x")
+	assert.Contains(t, output, "Amazon", "addresses outside the inventory fall back to cloud providers")
+
+	jsonOutput = true
+	output = captureOutput(func() { scanIPs([]string{"13.224.1.1"}) })
+	var results []provider.MatchResult
+	require.NoError(t, json.Unmarshal([]byte(output), &results))
+	require.Len(t, results, 1)
+	assert.Equal(t, "local", results[0].Provider)
+	require.NotNil(t, results[0].Meta)
+	assert.Equal(t, map[string]string{"site": "fra1"}, results[0].Meta.Labels)
+}
+
+func TestScanIPs_Assets(t *testing.T) {
+	dir := t.TempDir()
+	setupTestData(t, dir)
+	defer withDataDir(t, dir)()
+
+	export := filepath.Join(t.TempDir(), "addresses.json")
+	require.NoError(t, os.WriteFile(export, []byte(`{"Addresses": [{"PublicIp": "13.224.1.1", "NetworkInterfaceId": "eni-0abc", "NetworkInterfaceOwnerId": "1234"}]}`), 0644))
+	orig := assetFiles
+	assetFiles = []string{export}
+	t.Cleanup(func() { assetFiles = orig })
+
+	jsonOutput = false
+	output := captureOutput(func() { scanIPs([]string{"13.224.1.1", "13.224.2.1", "1.2.3.4"}) })
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "owned by account 1234 / eni-0abc")
+	assert.Contains(t, lines[1], "third party")
+	assert.NotContains(t, lines[2], "third party")
+
+	jsonOutput = true
+	output = captureOutput(func() { scanIPs([]string{"13.224.1.1", "13.224.2.1"}) })
+	var results []combinedResult
+	require.NoError(t, json.Unmarshal([]byte(output), &results))
+	require.Len(t, results, 2)
+	require.NotNil(t, results[0].Ownership)
+	assert.True(t, results[0].Ownership.Owned)
+	assert.Equal(t, "1234", results[0].Ownership.Asset.Account)
+	require.NotNil(t, results[1].Ownership)
+	assert.False(t, results[1].Ownership.Owned)
+	assert.Nil(t, results[1].Reputation)
+}
+
 func TestScanIPs_Stats(t *testing.T) {
 	dir := t.TempDir()
 	setupTestData(t, dir)
@@ -234,6 +390,31 @@ func TestScanIPs_NoDataWarning(t *testing.T) {
 	assert.Contains(t, string(errOut), "no provider data found")
 }
 
+func TestScanIPs_StaleWarning(t *testing.T) {
+	dir := t.TempDir()
+	setupTestData(t, dir)
+	defer withDataDir(t, dir)()
+	jsonOutput = false
+
+	errOut := captureStderr(func() {
+		captureOutput(func() { scanIPs([]string{"185.220.101.1"}) })
+	})
+	assert.Empty(t, errOut, "fresh data")
+
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "tor", "ipranges.json"), old, old))
+
+	errOut = captureStderr(func() {
+		captureOutput(func() { scanIPs([]string{"8.8.8.8"}) })
+	})
+	assert.Empty(t, errOut, "stale provider not matched")
+
+	errOut = captureStderr(func() {
+		captureOutput(func() { scanIPs([]string{"185.220.101.1", "185.220.101.2"}) })
+	})
+	assert.Equal(t, "Warning: tor data is older than 1h. Run 'ip-to-cloudprovider tor --update' for current ranges.\n", errOut)
+}
+
 // ---------------------------------------------------------------------------
 // readIPsFromFile tests
 // ---------------------------------------------------------------------------
@@ -267,6 +448,56 @@ func TestCollectIPs(t *testing.T) {
 	})
 }
 
+// ---------------------------------------------------------------------------
+// providersConfigArg tests
+// ---------------------------------------------------------------------------
+
+func TestProvidersConfigArg(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"separate value", []string{"-q", "--providers-config", "p.yaml", "examplehost", "-u"}, "p.yaml"},
+		{"equals sign", []string{"scan", "--providers-config=p.yaml", "1.2.3.4"}, "p.yaml"},
+		{"missing value", []string{"--providers-config"}, ""},
+		{"after terminator", []string{"scan", "--", "--providers-config=p.yaml"}, ""},
+		{"not given", []string{"list"}, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, providersConfigArg(tc.args))
+		})
+	}
+}
+
+func TestRegisterConfiguredProviders_CommandNames(t *testing.T) {
+	root := &cobra.Command{Use: "ip-to-cloudprovider"}
+	root.AddCommand(&cobra.Command{Use: "scan", Aliases: []string{"s"}}, &cobra.Command{Use: "list"})
+	reserved := commandNames(root)
+	assert.ElementsMatch(t, []string{"help", "completion", "scan", "s", "list"}, reserved)
+
+	origConfig, origRegistry := providersConfig, provider.Registry
+	t.Cleanup(func() {
+		providersConfig, provider.Registry = origConfig, origRegistry
+		providersCfg, providersCfgErr = provider.Config{}, nil
+	})
+
+	for _, name := range []string{"scan", "s", "help"} {
+		t.Run(name, func(t *testing.T) {
+			providersConfig = filepath.Join(t.TempDir(), "config.yaml")
+			content := fmt.Sprintf("providers:\n  - name: %s\n    url: https://example.com/ranges.txt\n", name)
+			require.NoError(t, os.WriteFile(providersConfig, []byte(content), 0644))
+
+			registerConfiguredProviders(reserved)
+			require.Error(t, providersCfgErr)
+			assert.Contains(t, providersCfgErr.Error(), fmt.Sprintf("name %q is taken by a command", name))
+			assert.Nil(t, provider.ByName(name), "not registered")
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // updateAllProviders tests
 // ---------------------------------------------------------------------------
@@ -278,18 +509,37 @@ func TestUpdateAllProviders(t *testing.T) {
 	dir := t.TempDir()
 	defer withDataDir(t, dir)()
 
+	// Point every URL-based provider and source at the mock server, and stub
+	// the multi-step fetchers so the test never touches the network.
 	originalURLs := make(map[int]string)
 	originalUpdates := make(map[int]provider.UpdateFunc)
 	for i := range provider.Registry {
 		originalURLs[i] = provider.Registry[i].URL
 		originalUpdates[i] = provider.Registry[i].Update
+		name := provider.Registry[i].Name
 		if provider.Registry[i].Parse != nil {
-			provider.Registry[i].URL = server.URL + "/" + provider.Registry[i].Name
+			provider.Registry[i].URL = server.URL + "/" + name
+		}
+		if provider.Registry[i].Update != nil {
+			provider.Registry[i].Update = func(dataDir string) error {
+				return provider.Save(name, extractTestCIDRs(mockProviderData[name]), dataDir)
+			}
+		}
+	}
+	originalSources := append([]provider.Source(nil), provider.Sources...)
+	for i := range provider.Sources {
+		s := &provider.Sources[i]
+		if s.Split != nil {
+			s.URL = server.URL + "/" + s.Name
 		}
-		if provider.Registry[i].Name == "microsoft" {
-			idx := i
-			provider.Registry[idx].Update = func(dataDir string) error {
-				return provider.Save("microsoft", &IPRange{IPv4: []string{"20.0.0.0/8"}}, dataDir)
+		if s.Collect != nil {
+			members := s.Members()
+			s.Collect = func() (map[string]*IPRange, error) {
+				ranges := make(map[string]*IPRange)
+				for _, name := range members {
+					ranges[name] = extractTestCIDRs(mockProviderData[name])
+				}
+				return ranges, nil
 			}
 		}
 	}
@@ -298,6 +548,7 @@ func TestUpdateAllProviders(t *testing.T) {
 			provider.Registry[i].URL = originalURLs[i]
 			provider.Registry[i].Update = originalUpdates[i]
 		}
+		provider.Sources = originalSources
 	}()
 
 	output := captureOutput(func() { updateAllProviders() })
@@ -338,6 +589,347 @@ func TestListProviders(t *testing.T) {
 		assert.Contains(t, infos[0], "name")
 		assert.Contains(t, infos[0], "has_data")
 	})
+
+	t.Run("category filter", func(t *testing.T) {
+		defer func() { categoryFilter = nil }()
+		categoryFilter = []string{provider.CategoryCDN}
+
+		jsonOutput = true
+		output := captureOutput(func() { listProviders() })
+		var infos []map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(output), &infos))
+		require.NotEmpty(t, infos)
+		for _, info := range infos {
+			assert.Equal(t, provider.CategoryCDN, info["category"], info["name"])
+			assert.NotEmpty(t, info["display_name"], info["name"])
+		}
+	})
+
+	t.Run("short-lived data goes stale", func(t *testing.T) {
+		old := time.Now().Add(-2 * time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "tor", "ipranges.json"), old, old))
+
+		jsonOutput = false
+		output := captureOutput(func() { listProviders() })
+		assert.Contains(t, output, "stale")
+		assert.Contains(t, output, "older than 1h")
+
+		jsonOutput = true
+		output = captureOutput(func() { listProviders() })
+		var infos []map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(output), &infos))
+		for _, info := range infos {
+			if info["name"] == "tor" {
+				assert.Equal(t, true, info["stale"])
+			} else {
+				assert.NotContains(t, info, "stale", info["name"])
+			}
+		}
+	})
+}
+
+// ---------------------------------------------------------------------------
+// showOverlaps tests
+// ---------------------------------------------------------------------------
+
+func TestShowOverlaps(t *testing.T) {
+	dir := t.TempDir()
+	setupTestData(t, dir)
+	defer withDataDir(t, dir)()
+
+	t.Run("json output", func(t *testing.T) {
+		jsonOutput = true
+		output := captureOutput(func() { showOverlaps([]string{"amazon", "cloudfront", "cloudflare"}) })
+
+		var overlaps []provider.Overlap
+		require.NoError(t, json.Unmarshal([]byte(output), &overlaps))
+		require.Len(t, overlaps, 1)
+		assert.Equal(t, "amazon", overlaps[0].A, "named providers keep their order")
+		assert.Equal(t, "cloudfront", overlaps[0].B)
+		assert.Equal(t, []string{"18.160.0.0/15"}, overlaps[0].IPv4)
+		assert.Equal(t, int64(131072), overlaps[0].IPv4Addresses.Int64())
+	})
+
+	t.Run("text matrix", func(t *testing.T) {
+		jsonOutput = false
+		output := captureOutput(func() { showOverlaps([]string{"amazon", "cloudfront", "cloudflare"}) })
+		assert.Contains(t, output, "1 amazon")
+		assert.Contains(t, output, "2 cloudfront")
+		assert.NotContains(t, output, "cloudflare", "providers without overlaps are not shown")
+		assert.Contains(t, output, "131.1k")
+		assert.Contains(t, output, "amazon & cloudfront")
+	})
+
+	t.Run("no overlaps", func(t *testing.T) {
+		jsonOutput = false
+		output := captureOutput(func() { showOverlaps([]string{"cloudflare", "hetzner"}) })
+		assert.Contains(t, output, "No overlaps between 2 providers")
+
+		jsonOutput = true
+		output = captureOutput(func() { showOverlaps([]string{"cloudflare", "hetzner"}) })
+		assert.JSONEq(t, "[]", output)
+	})
+}
+
+// ---------------------------------------------------------------------------
+// showProviderStats tests
+// ---------------------------------------------------------------------------
+
+func TestShowProviderStats(t *testing.T) {
+	dir := t.TempDir()
+	setupTestData(t, dir)
+	defer withDataDir(t, dir)()
+
+	t.Run("json output", func(t *testing.T) {
+		jsonOutput = true
+		output := captureOutput(func() { showProviderStats([]string{"oracle", "cloudflare"}, false, 10) })
+
+		var report struct {
+			Providers []provider.Stats `json:"providers"`
+			Total     provider.Stats   `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(output), &report))
+		require.Len(t, report.Providers, 2)
+		assert.Equal(t, "oracle", report.Providers[0].Provider)
+		require.NotEmpty(t, report.Providers[0].Regions)
+		assert.Equal(t, "total", report.Total.Provider)
+		assert.Equal(t, report.Providers[0].IPv4Prefixes+report.Providers[1].IPv4Prefixes, report.Total.IPv4Prefixes)
+		assert.Empty(t, report.Total.LargestIPv4)
+	})
+
+	t.Run("text table", func(t *testing.T) {
+		jsonOutput = false
+		output := captureOutput(func() { showProviderStats([]string{"oracle", "cloudflare"}, false, 10) })
+		assert.Contains(t, output, "ROUTABLE")
+		assert.Contains(t, output, "oracle")
+		assert.Contains(t, output, "total (2 providers)")
+		assert.NotContains(t, output, "Regions")
+	})
+
+	t.Run("text detail", func(t *testing.T) {
+		jsonOutput = false
+		output := captureOutput(func() { showProviderStats([]string{"oracle"}, true, 1) })
+		assert.Contains(t, output, "largest")
+		assert.Contains(t, output, "Regions")
+		assert.Contains(t, output, "Services")
+	})
+}
+
+func TestExportProviders(t *testing.T) {
+	dir := t.TempDir()
+	setupTestData(t, dir)
+	defer withDataDir(t, dir)()
+
+	t.Run("stdout", func(t *testing.T) {
+		output := captureOutput(func() {
+			exportProviders([]string{"githubhooks", "cloudflare"}, exportOptions{format: "nginx", action: "allow"})
+		})
+		assert.Equal(t, `# githubhooks-cloudflare: 3 IPv4 and 1 IPv6 prefixes from githubhooks, cloudflare, generated by ip-to-cloudprovider
+allow 104.16.0.0/13;
+allow 140.82.112.0/20;
+allow 198.41.128.0/17;
+allow 2400:cb00::/32;
+deny all;
+`, output)
+	})
+
+	t.Run("output file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cf.ipset")
+		output := captureOutput(func() {
+			exportProviders([]string{"cloudflare"}, exportOptions{format: "ipset", action: "deny", name: "cf", output: path, ipv4Only: true})
+		})
+		assert.Contains(t, output, "Wrote 2 IPv4 and 0 IPv6 prefixes of cloudflare to "+path)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "add cf-v4 198.41.128.0/17\n")
+		assert.NotContains(t, string(data), "cf-v6")
+	})
+
+	t.Run("lookup csv carries metadata", func(t *testing.T) {
+		output := captureOutput(func() {
+			exportProviders([]string{"oracle"}, exportOptions{format: "lookup-csv", action: "allow"})
+		})
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		assert.Equal(t, "cidr,provider,service,region", lines[0])
+		assert.Contains(t, lines, "129.146.0.0/21,oracle,OCI,us-phoenix-1")
+	})
+
+	t.Run("stale data warns", func(t *testing.T) {
+		old := time.Now().Add(-2 * time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "tor", "ipranges.json"), old, old))
+
+		var output string
+		errOut := captureStderr(func() {
+			output = captureOutput(func() {
+				exportProviders([]string{"tor"}, exportOptions{format: "nginx", action: "deny"})
+			})
+		})
+		assert.Contains(t, output, "deny 185.220.101.1/32;")
+		assert.Contains(t, errOut, "tor data is older than 1h")
+	})
+
+	t.Run("cloud objects split at the limit", func(t *testing.T) {
+		output := captureOutput(func() {
+			exportProviders([]string{"cloudflare"}, exportOptions{format: "aws-waf", action: "allow", maxEntries: 1})
+		})
+		var sets []struct {
+			Name      string   `json:"Name"`
+			Addresses []string `json:"Addresses"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(output), &sets))
+		require.Len(t, sets, 3)
+		assert.Equal(t, "cloudflare-v4-2", sets[1].Name)
+		assert.Equal(t, []string{"198.41.128.0/17"}, sets[1].Addresses)
+	})
+}
+
+func TestPrintAllowlist(t *testing.T) {
+	dir := t.TempDir()
+	setupTestData(t, dir)
+	defer withDataDir(t, dir)()
+
+	want, err := provider.BuildAllowlist([]string{"githubhooks", "github"}, 0, dir)
+	require.NoError(t, err)
+
+	t.Run("text output", func(t *testing.T) {
+		jsonOutput = false
+		output := captureOutput(func() { printAllowlist([]string{"githubhooks", "github"}, allowlistOptions{}) })
+		assert.Equal(t, `# allowlist for github, githubhooks
+# 2 prefixes (2 IPv4, 0 IPv6), aggregated from 2
+# sha256 `+want.SHA256+`
+140.82.112.0/20
+192.30.252.0/22
+`, output)
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		jsonOutput = true
+		output := captureOutput(func() { printAllowlist([]string{"githubhooks", "github"}, allowlistOptions{}) })
+		var got provider.Allowlist
+		require.NoError(t, json.Unmarshal([]byte(output), &got))
+		assert.Equal(t, *want, got)
+	})
+
+	t.Run("output file", func(t *testing.T) {
+		jsonOutput = false
+		path := filepath.Join(t.TempDir(), "allow.txt")
+		output := captureOutput(func() {
+			printAllowlist([]string{"cloudflare"}, allowlistOptions{ipv6Only: true, output: path})
+		})
+		assert.Contains(t, output, "Wrote 1 prefixes")
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(string(data), "\n2400:cb00::/32\n"))
+		assert.NotContains(t, string(data), "104.16.0.0/13")
+	})
+
+	t.Run("verify unchanged", func(t *testing.T) {
+		output := captureOutput(func() {
+			printAllowlist([]string{"github", "githubhooks"}, allowlistOptions{verify: strings.ToUpper(want.SHA256)})
+		})
+		assert.Equal(t, "Allowlist unchanged (sha256 "+want.SHA256+")\n", output)
+	})
+}
+
+func TestBuildExportList(t *testing.T) {
+	dir := t.TempDir()
+	setupTestData(t, dir)
+	defer withDataDir(t, dir)()
+
+	t.Run("duplicates removed without aggregation", func(t *testing.T) {
+		list, err := buildExportList([]string{"github", "githubhooks"}, exportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "github-githubhooks", list.Name)
+		assert.Equal(t, []string{"github", "githubhooks"}, list.Sources)
+		assert.Equal(t, []string{"140.82.112.0/20", "192.30.252.0/22"}, cidr.Strings(list.IPv4))
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		list, err := buildExportList([]string{"amazon"}, exportOptions{name: "aws", aggregate: true})
+		require.NoError(t, err)
+		assert.Equal(t, "aws", list.Name)
+		assert.Equal(t, []string{"13.224.0.0/14", "18.160.0.0/15", "52.94.76.0/22"}, cidr.Strings(list.IPv4))
+	})
+
+	t.Run("ipv6 only", func(t *testing.T) {
+		list, err := buildExportList([]string{"cloudflare"}, exportOptions{ipv6Only: true})
+		require.NoError(t, err)
+		assert.Empty(t, list.IPv4)
+		assert.Equal(t, []string{"2400:cb00::/32"}, cidr.Strings(list.IPv6))
+	})
+
+	t.Run("shared prefix attributed by priority", func(t *testing.T) {
+		list, err := buildExportList([]string{"amazon", "cloudfront"}, exportOptions{})
+		require.NoError(t, err)
+		attr := list.Attrs[netip.MustParsePrefix("18.160.0.0/15")]
+		assert.Equal(t, "cloudfront", attr.Provider)
+		assert.Equal(t, "Amazon CloudFront", attr.DisplayName)
+		assert.Equal(t, "amazon", list.Attrs[netip.MustParsePrefix("13.224.0.0/14")].Provider)
+	})
+
+	t.Run("metadata carried", func(t *testing.T) {
+		list, err := buildExportList([]string{"oracle"}, exportOptions{})
+		require.NoError(t, err)
+		attr := list.Attrs[list.IPv4[0]]
+		require.NotNil(t, attr.Meta)
+		assert.Equal(t, "us-phoenix-1", attr.Meta.Region)
+	})
+
+	t.Run("default name of a category selection", func(t *testing.T) {
+		categoryFilter = []string{"ci"}
+		defer func() { categoryFilter = nil }()
+		list, err := buildExportList(nil, exportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "ci", list.Name)
+	})
+
+	t.Run("per-prefix format cannot aggregate", func(t *testing.T) {
+		_, err := buildExportList([]string{"oracle"}, exportOptions{format: "mmdb", aggregate: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "mmdb keeps per-prefix details")
+	})
+
+	t.Run("nothing left", func(t *testing.T) {
+		_, err := buildExportList([]string{"githubhooks"}, exportOptions{ipv6Only: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no prefixes to export")
+	})
+}
+
+func TestFormatShare(t *testing.T) {
+	assert.Equal(t, "0%", formatShare(0))
+	assert.Equal(t, "<0.01%", formatShare(0.00005))
+	assert.Equal(t, "2.75%", formatShare(0.0275))
+}
+
+func TestPrefixSizes(t *testing.T) {
+	assert.Equal(t, "-", prefixSizes("", ""))
+	assert.Equal(t, "/32", prefixSizes("1.2.3.4/32", "1.2.3.5/32"))
+	assert.Equal(t, "/11-/32", prefixSizes("3.0.0.0/11", "1.2.3.4/32"))
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		n    *big.Int
+		want string
+	}{
+		{big.NewInt(0), "0"},
+		{big.NewInt(9999), "9999"},
+		{big.NewInt(131072), "131.1k"},
+		{big.NewInt(16777216), "16.8M"},
+		{new(big.Int).Lsh(big.NewInt(1), 32), "4.3G"},
+		{new(big.Int).Lsh(big.NewInt(1), 96), "2^96.0"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, formatCount(tc.n))
+	}
+}
+
+func TestFormatInterval(t *testing.T) {
+	assert.Equal(t, "1h", formatInterval(time.Hour))
+	assert.Equal(t, "30m", formatInterval(30*time.Minute))
+	assert.Equal(t, "1h30m", formatInterval(90*time.Minute))
+	assert.Equal(t, "45s", formatInterval(45*time.Second))
 }
 
 // ---------------------------------------------------------------------------
@@ -353,6 +945,35 @@ func TestCapitalizeFirst(t *testing.T) {
 	}
 }
 
+func TestDescribeMeta(t *testing.T) {
+	assert.Equal(t, "", describeMeta(nil))
+	assert.Equal(t, "", describeMeta(&provider.PrefixMeta{}))
+	assert.Contains(t, describeMeta(&provider.PrefixMeta{ASN: 24940}), "AS24940")
+	assert.Contains(t, describeMeta(&provider.PrefixMeta{Region: "us-phoenix-1", Tags: []string{"OCI"}}), "us-phoenix-1; OCI")
+	assert.Contains(t, describeMeta(&provider.PrefixMeta{Country: "NL", City: "Amsterdam"}), "Amsterdam, NL")
+	assert.Contains(t, describeMeta(&provider.PrefixMeta{
+		Name: "corp-vpn", Owner: "team-x", Environment: "prod",
+		Labels: map[string]string{"site": "fra1", "critical": ""},
+	}), "corp-vpn / team-x; prod; critical, site=fra1")
+	assert.Contains(t, describeMeta(&provider.PrefixMeta{Owner: "team-y"}), "(team-y)")
+}
+
+func TestNamedColor(t *testing.T) {
+	assert.NotNil(t, namedColor("red"))
+	assert.NotNil(t, namedColor(" Hi-Magenta "))
+	assert.Nil(t, namedColor("chartreuse"))
+	assert.Nil(t, namedColor("hi-"))
+	assert.NotNil(t, namedColor("black on white"))
+	assert.NotNil(t, namedColor("hi-red on yellow"))
+	assert.Nil(t, namedColor("red on chartreuse"))
+	assert.Nil(t, namedColor("red on hi-"))
+}
+
+func TestProviderTitle(t *testing.T) {
+	assert.Equal(t, "Amazon CloudFront", providerTitle(provider.ByName("cloudfront")))
+	assert.Equal(t, "Examplehost", providerTitle(&provider.Provider{Name: "examplehost"}))
+}
+
 // Type aliases for test helpers
 type IPRange = provider.IPRange
 type UpdateFunc = provider.UpdateFunc
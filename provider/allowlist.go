@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/BenjiTrapp/ip-to-cloudprovider/cidr"
+)
+
+// Allowlist is the minimal prefix set covering a group of providers.
+type Allowlist struct {
+	Providers []string `json:"providers"`
+	IPv4      []string `json:"ipv4"`
+	IPv6      []string `json:"ipv6"`
+
+	// SourcePrefixes is the number of prefixes the providers publish for the
+	// selected families, before aggregation.
+	SourcePrefixes int `json:"source_prefixes"`
+
+	// SHA256 is the hex digest of the prefixes, IPv4 before IPv6, each
+	// followed by a newline: exactly the body of the plain-text list. It
+	// changes whenever the covered address space does, and only then.
+	SHA256 string `json:"sha256"`
+}
+
+// BuildAllowlist aggregates the stored ranges of the named providers into
+// the fewest prefixes covering exactly the same addresses, sorted by address.
+// family restricts the list to IPv4 (4) or IPv6 (6); 0 keeps both. Every
+// provider must be registered and have data, since an allowlist that silently
+// misses a service breaks whatever relies on it.
+func BuildAllowlist(names []string, family int, dataDir string) (*Allowlist, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no providers given")
+	}
+	var prefixes []netip.Prefix
+	var providers []string
+	for _, name := range names {
+		if ByName(name) == nil {
+			return nil, fmt.Errorf("unknown provider %q", name)
+		}
+		if slices.Contains(providers, name) {
+			continue
+		}
+		ipRange, err := Load(name, dataDir)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s: %w", name, err)
+		}
+		prefixes = append(prefixes, ipRange.Prefixes()...)
+		providers = append(providers, name)
+	}
+	slices.Sort(providers)
+
+	ipv4, ipv6 := splitFamilies(prefixes)
+	switch family {
+	case 0:
+	case 4:
+		ipv6 = nil
+	case 6:
+		ipv4 = nil
+	default:
+		return nil, fmt.Errorf("invalid address family %d", family)
+	}
+	a := newAllowlist(providers, cidr.Aggregate(ipv4), cidr.Aggregate(ipv6))
+	a.SourcePrefixes = len(ipv4) + len(ipv6)
+	return a, nil
+}
+
+// Lines returns the prefixes in list order.
+func (a *Allowlist) Lines() []string {
+	return append(slices.Clone(a.IPv4), a.IPv6...)
+}
+
+func newAllowlist(providers []string, ipv4, ipv6 []netip.Prefix) *Allowlist {
+	a := &Allowlist{Providers: providers, IPv4: cidr.Strings(ipv4), IPv6: cidr.Strings(ipv6)}
+	if a.IPv4 == nil {
+		a.IPv4 = []string{}
+	}
+	if a.IPv6 == nil {
+		a.IPv6 = []string{}
+	}
+	var body strings.Builder
+	for _, line := range a.Lines() {
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	sum := sha256.Sum256([]byte(body.String()))
+	a.SHA256 = hex.EncodeToString(sum[:])
+	return a
+}
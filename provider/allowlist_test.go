@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildAllowlist(t *testing.T) {
+	withRegistry(t, []Provider{{Name: "hooks"}, {Name: "actions"}, {Name: "nodata"}}, nil)
+	dir := t.TempDir()
+	require.NoError(t, Save("hooks", &IPRange{
+		IPv4: []string{"140.82.112.0/20", "192.30.252.0/22"},
+		IPv6: []string{"2a0a:a440::/29"},
+	}, dir))
+	require.NoError(t, Save("actions", &IPRange{
+		IPv4: []string{"140.82.112.0/21", "192.30.254.0/24", "192.30.248.0/22", "4.148.0.0/16"},
+		IPv6: []string{"2a0a:a440::/32"},
+	}, dir))
+
+	list, err := BuildAllowlist([]string{"hooks", "actions", "hooks"}, 0, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"actions", "hooks"}, list.Providers, "sorted and deduplicated")
+	assert.Equal(t, []string{"4.148.0.0/16", "140.82.112.0/20", "192.30.248.0/21"}, list.IPv4)
+	assert.Equal(t, []string{"2a0a:a440::/29"}, list.IPv6)
+	assert.Equal(t, 8, list.SourcePrefixes)
+
+	body := "4.148.0.0/16\n140.82.112.0/20\n192.30.248.0/21\n2a0a:a440::/29\n"
+	sum := sha256.Sum256([]byte(body))
+	assert.Equal(t, hex.EncodeToString(sum[:]), list.SHA256, "checksum of the plain list body")
+
+	t.Run("order of providers does not matter", func(t *testing.T) {
+		other, err := BuildAllowlist([]string{"actions", "hooks"}, 0, dir)
+		require.NoError(t, err)
+		assert.Equal(t, list.SHA256, other.SHA256)
+	})
+
+	t.Run("redundant prefixes do not change the checksum", func(t *testing.T) {
+		hooksOnly, err := BuildAllowlist([]string{"hooks"}, 0, dir)
+		require.NoError(t, err)
+		assert.NotEqual(t, list.SHA256, hooksOnly.SHA256)
+
+		require.NoError(t, Save("actions", &IPRange{IPv4: []string{"140.82.112.0/24"}}, dir))
+		covered, err := BuildAllowlist([]string{"hooks", "actions"}, 0, dir)
+		require.NoError(t, err)
+		assert.Equal(t, hooksOnly.IPv4, covered.IPv4)
+		assert.Equal(t, hooksOnly.SHA256, covered.SHA256)
+	})
+
+	t.Run("single family", func(t *testing.T) {
+		v6, err := BuildAllowlist([]string{"hooks"}, 6, dir)
+		require.NoError(t, err)
+		assert.Empty(t, v6.IPv4)
+		assert.NotNil(t, v6.IPv4, "empty families marshal as []")
+		assert.Equal(t, []string{"2a0a:a440::/29"}, v6.Lines())
+		assert.Equal(t, 1, v6.SourcePrefixes)
+	})
+
+	tests := []struct {
+		name    string
+		names   []string
+		family  int
+		wantErr string
+	}{
+		{"no providers", nil, 0, "no providers given"},
+		{"unknown provider", []string{"hooks", "typo"}, 0, `unknown provider "typo"`},
+		{"provider without data", []string{"nodata"}, 0, "loading nodata"},
+		{"invalid family", []string{"hooks"}, 5, "invalid address family 5"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := BuildAllowlist(tc.names, tc.family, dir)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
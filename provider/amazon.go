@@ -3,23 +3,60 @@ package provider
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 func init() {
-	Register(Provider{
+	RegisterSource(Source{
 		Name:  "amazon",
 		URL:   "https://ip-ranges.amazonaws.com/ip-ranges.json",
-		Parse: parseAmazon,
+		Split: splitAmazon,
+	})
+	// CloudFront is registered first: its prefixes are also part of "amazon",
+	// and an edge address should be reported as the CDN.
+	Register(Provider{
+		Name:        "cloudfront",
+		URL:         "https://ip-ranges.amazonaws.com/ip-ranges.json",
+		Parse:       parseCloudFront,
+		Source:      "amazon",
+		DisplayName: "Amazon CloudFront",
+		Category:    CategoryCDN,
+		Color:       "hi-yellow",
+		Homepage:    "https://docs.aws.amazon.com/AmazonCloudFront/latest/DeveloperGuide/LocationsOfEdgeServers.html",
+		License:     LicenseUnstated,
+	})
+	Register(Provider{
+		Name:        "amazon",
+		URL:         "https://ip-ranges.amazonaws.com/ip-ranges.json",
+		Parse:       parseAmazon,
+		Source:      "amazon",
+		DisplayName: "Amazon AWS",
+		Category:    CategoryCloud,
+		Color:       "yellow",
+		Homepage:    "https://docs.aws.amazon.com/vpc/latest/userguide/aws-ip-ranges.html",
+		License:     LicenseUnstated,
 	})
 }
 
-func parseAmazon(data []byte) (*IPRange, error) {
+// amazonPrefix is one entry of ip-ranges.json. The same prefix is listed once
+// per service using it (e.g. AMAZON and EC2).
+type amazonPrefix struct {
+	Prefix  string
+	Region  string
+	Service string
+}
+
+func parseAmazonPrefixes(data []byte) ([]amazonPrefix, error) {
 	var result struct {
 		Prefixes []struct {
 			IPPrefix string `json:"ip_prefix"`
+			Region   string `json:"region"`
+			Service  string `json:"service"`
 		} `json:"prefixes"`
 		IPv6Prefixes []struct {
 			IPv6Prefix string `json:"ipv6_prefix"`
+			Region     string `json:"region"`
+			Service    string `json:"service"`
 		} `json:"ipv6_prefixes"`
 	}
 
@@ -27,13 +64,86 @@ func parseAmazon(data []byte) (*IPRange, error) {
 		return nil, fmt.Errorf("parsing Amazon data: %w", err)
 	}
 
-	ipRange := &IPRange{}
+	var prefixes []amazonPrefix
 	for _, p := range result.Prefixes {
-		ipRange.IPv4 = append(ipRange.IPv4, p.IPPrefix)
+		prefixes = append(prefixes, amazonPrefix{p.IPPrefix, p.Region, p.Service})
 	}
 	for _, p := range result.IPv6Prefixes {
-		ipRange.IPv6 = append(ipRange.IPv6, p.IPv6Prefix)
+		prefixes = append(prefixes, amazonPrefix{p.IPv6Prefix, p.Region, p.Service})
+	}
+	return prefixes, nil
+}
+
+// splitAmazon parses ip-ranges.json once into all of AWS ("amazon") and the
+// CloudFront edge ranges ("cloudfront"), keeping each prefix's region and
+// services.
+func splitAmazon(data []byte) (map[string]*IPRange, error) {
+	prefixes, err := parseAmazonPrefixes(data)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]*IPRange{
+		"amazon":     amazonRange(prefixes, ""),
+		"cloudfront": amazonRange(prefixes, "CLOUDFRONT"),
+	}, nil
+}
+
+func parseAmazon(data []byte) (*IPRange, error) {
+	ranges, err := splitAmazon(data)
+	if err != nil {
+		return nil, err
+	}
+	return ranges["amazon"], nil
+}
+
+func parseCloudFront(data []byte) (*IPRange, error) {
+	ranges, err := splitAmazon(data)
+	if err != nil {
+		return nil, err
+	}
+	return ranges["cloudfront"], nil
+}
+
+// amazonRange collects the prefixes used by service (all prefixes when service
+// is empty). Each prefix is listed once, tagged with every service using it.
+func amazonRange(prefixes []amazonPrefix, service string) *IPRange {
+	ipRange := &IPRange{}
+	index := make(map[string]int)
+	var metas []PrefixMeta
+
+	for _, p := range prefixes {
+		if service != "" && p.Service != service {
+			continue
+		}
+		i, ok := index[p.Prefix]
+		if !ok {
+			i = len(metas)
+			index[p.Prefix] = i
+			metas = append(metas, PrefixMeta{Region: p.Region})
+			if strings.Contains(p.Prefix, ":") {
+				ipRange.IPv6 = append(ipRange.IPv6, p.Prefix)
+			} else {
+				ipRange.IPv4 = append(ipRange.IPv4, p.Prefix)
+			}
+		}
+		if p.Service != "" && p.Service != "AMAZON" && p.Service != service {
+			metas[i].Tags = appendTag(metas[i].Tags, p.Service)
+		}
 	}
 
-	return ipRange, nil
+	for prefix, i := range index {
+		if metas[i].Region != "" || len(metas[i].Tags) > 0 {
+			ipRange.setMeta(prefix, metas[i])
+		}
+	}
+	return ipRange
+}
+
+func appendTag(tags []string, tag string) []string {
+	for _, t := range tags {
+		if t == tag {
+			return tags
+		}
+	}
+	return append(tags, tag)
 }
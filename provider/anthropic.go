@@ -1,10 +1,13 @@
 package provider
 
 import (
+	"bytes"
 	"fmt"
 	"net"
 	"regexp"
 	"strings"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
 // privateAndReservedNetworks contains CIDRs that should never appear in
@@ -54,77 +57,192 @@ const anthropicDocsURL = "https://docs.anthropic.com/en/api/ip-addresses"
 var cidrRegex = regexp.MustCompile(`(?:(?:\d{1,3}\.){3}\d{1,3}/\d{1,2}|[0-9a-fA-F:]+::/\d{1,3})`)
 
 func init() {
+	RegisterSource(Source{
+		Name:  "anthropic",
+		URL:   anthropicDocsURL,
+		Split: splitAnthropic,
+	})
+	Register(Provider{
+		Name:        "anthropic-inbound",
+		URL:         anthropicDocsURL,
+		Source:      "anthropic",
+		DisplayName: "Anthropic (inbound)",
+		Category:    CategoryAI,
+		Color:       "hi-magenta",
+		Homepage:    anthropicDocsURL,
+		License:     LicenseUnstated,
+	})
 	Register(Provider{
-		Name:   "anthropic",
-		URL:    anthropicDocsURL,
-		Update: updateAnthropic,
+		Name:        "anthropic-outbound",
+		URL:         anthropicDocsURL,
+		Source:      "anthropic",
+		DisplayName: "Anthropic (outbound)",
+		Category:    CategoryAI,
+		Color:       "hi-magenta",
+		Homepage:    anthropicDocsURL,
+		License:     LicenseUnstated,
+	})
+	// Union of inbound and outbound, kept for existing users and snapshots.
+	Register(Provider{
+		Name:        "anthropic",
+		URL:         anthropicDocsURL,
+		Source:      "anthropic",
+		DisplayName: "Anthropic",
+		Category:    CategoryAI,
+		Color:       "hi-magenta",
+		Homepage:    anthropicDocsURL,
+		License:     LicenseUnstated,
 	})
 }
 
-// updateAnthropic fetches the Anthropic docs page and extracts CIDR ranges.
-// Anthropic does not provide a machine-readable API; their IP ranges are
-// documented at https://docs.anthropic.com/en/api/ip-addresses
-func updateAnthropic(dataDir string) error {
-	body, err := Fetch(anthropicDocsURL)
-	if err != nil {
-		return fmt.Errorf("fetching Anthropic IP docs: %w", err)
+// anthropicSection identifies which part of the docs page a node belongs to.
+type anthropicSection int
+
+const (
+	anthropicNone anthropicSection = iota
+	anthropicInbound
+	anthropicOutbound
+	anthropicSkipped // phased-out or deprecated addresses
+)
+
+// classifyAnthropicHeading maps a heading's text to the section it starts.
+func classifyAnthropicHeading(text string) anthropicSection {
+	text = strings.ToLower(text)
+	switch {
+	case strings.Contains(text, "phased out"), strings.Contains(text, "deprecated"):
+		return anthropicSkipped
+	case strings.Contains(text, "inbound"):
+		return anthropicInbound
+	case strings.Contains(text, "outbound"):
+		return anthropicOutbound
 	}
+	return anthropicNone
+}
 
-	ipRange, err := parseAnthropic(body)
+// splitAnthropic parses the Anthropic docs page into inbound and outbound
+// ranges. Anthropic does not provide a machine-readable API; their IP ranges
+// are documented at https://docs.anthropic.com/en/api/ip-addresses
+func splitAnthropic(data []byte) (map[string]*IPRange, error) {
+	inbound, outbound, err := parseAnthropicSections(data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return Save("anthropic", ipRange, dataDir)
-}
-
-// parseAnthropic extracts CIDR ranges from the Anthropic docs page content.
-// It uses regex to find valid CIDR patterns and deduplicates them.
-func parseAnthropic(data []byte) (*IPRange, error) {
-	content := string(data)
+	union := &IPRange{}
+	appendUnique(union, inbound)
+	appendUnique(union, outbound)
 
-	// Remove phased-out IP section to avoid including deprecated ranges
-	if idx := strings.Index(content, "Phased out"); idx != -1 {
-		content = content[:idx]
-	}
-	if idx := strings.Index(content, "phased out"); idx != -1 {
-		content = content[:idx]
-	}
+	return map[string]*IPRange{
+		"anthropic-inbound":  inbound,
+		"anthropic-outbound": outbound,
+		"anthropic":          union,
+	}, nil
+}
 
-	matches := cidrRegex.FindAllString(content, -1)
-	if len(matches) == 0 {
-		return nil, fmt.Errorf("no CIDR ranges found in Anthropic docs page")
+// parseAnthropicSections walks the docs page in document order and assigns
+// every CIDR to the "Inbound" or "Outbound" section whose heading precedes it.
+// Sub-headings (e.g. "IPv4") stay within the enclosing section, while a
+// "Phased out" heading or an unrelated heading at the same or a higher level
+// ends it. Both sections must be present and non-empty: if the page layout
+// changes, the update fails instead of silently saving partial data.
+func parseAnthropicSections(data []byte) (inbound, outbound *IPRange, err error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing Anthropic docs HTML: %w", err)
 	}
 
-	ipRange := &IPRange{}
+	inbound, outbound = &IPRange{}, &IPRange{}
+	found := make(map[anthropicSection]bool)
 	seen := make(map[string]bool)
 
-	for _, cidr := range matches {
-		cidr = strings.TrimSpace(cidr)
-		// Validate it's actually a valid CIDR
-		_, _, err := net.ParseCIDR(cidr)
-		if err != nil {
-			continue
+	current, currentLevel := anthropicNone, 0
+	doc.Find("h1, h2, h3, h4, h5, h6, code, li, td, p").Each(func(_ int, s *goquery.Selection) {
+		tag := goquery.NodeName(s)
+		if len(tag) == 2 && tag[0] == 'h' {
+			level := int(tag[1] - '0')
+			if section := classifyAnthropicHeading(s.Text()); section != anthropicNone {
+				current, currentLevel = section, level
+				found[section] = true
+			} else if level <= currentLevel {
+				current, currentLevel = anthropicNone, 0
+			}
+			return
 		}
-		if seen[cidr] {
-			continue
+
+		var target *IPRange
+		switch current {
+		case anthropicInbound:
+			target = inbound
+		case anthropicOutbound:
+			target = outbound
+		default:
+			return
 		}
-		// Skip private/reserved ranges (e.g. documentation examples)
-		if isPrivateOrReserved(cidr) {
-			continue
+
+		for _, cidr := range cidrRegex.FindAllString(s.Text(), -1) {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				continue
+			}
+			// Skip private/reserved ranges (e.g. documentation examples)
+			key := fmt.Sprintf("%d/%s", current, cidr)
+			if seen[key] || isPrivateOrReserved(cidr) {
+				continue
+			}
+			seen[key] = true
+
+			if ClassifyCIDR(cidr) {
+				target.IPv6 = append(target.IPv6, cidr)
+			} else {
+				target.IPv4 = append(target.IPv4, cidr)
+			}
 		}
-		seen[cidr] = true
+	})
 
-		if ClassifyCIDR(cidr) {
-			ipRange.IPv6 = append(ipRange.IPv6, cidr)
-		} else {
-			ipRange.IPv4 = append(ipRange.IPv4, cidr)
+	for _, want := range []struct {
+		section anthropicSection
+		name    string
+		ranges  *IPRange
+	}{
+		{anthropicInbound, "inbound", inbound},
+		{anthropicOutbound, "outbound", outbound},
+	} {
+		if !found[want.section] {
+			return nil, nil, fmt.Errorf("no %s IP address section found in Anthropic docs page", want.name)
+		}
+		if len(want.ranges.IPv4) == 0 && len(want.ranges.IPv6) == 0 {
+			return nil, nil, fmt.Errorf("no CIDR ranges found in %s section of Anthropic docs page", want.name)
 		}
 	}
 
-	if len(ipRange.IPv4) == 0 && len(ipRange.IPv6) == 0 {
-		return nil, fmt.Errorf("no valid CIDR ranges extracted from Anthropic docs")
-	}
+	return inbound, outbound, nil
+}
 
-	return ipRange, nil
+// appendUnique adds the CIDRs of src, with their metadata, to dst, skipping
+// ones dst already holds.
+func appendUnique(dst, src *IPRange) {
+	seen := make(map[string]bool, len(dst.IPv4)+len(dst.IPv6))
+	for _, cidr := range dst.IPv4 {
+		seen[cidr] = true
+	}
+	for _, cidr := range dst.IPv6 {
+		seen[cidr] = true
+	}
+	for _, cidr := range src.IPv4 {
+		if !seen[cidr] {
+			seen[cidr] = true
+			dst.IPv4 = append(dst.IPv4, cidr)
+			if meta, ok := src.Meta[cidr]; ok {
+				dst.setMeta(cidr, meta)
+			}
+		}
+	}
+	for _, cidr := range src.IPv6 {
+		if !seen[cidr] {
+			seen[cidr] = true
+			dst.IPv6 = append(dst.IPv6, cidr)
+			if meta, ok := src.Meta[cidr]; ok {
+				dst.setMeta(cidr, meta)
+			}
+		}
+	}
 }
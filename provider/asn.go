@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultASNURLTemplate points at the ipverse/asn-ip aggregated prefix lists.
+// "{asn}" is replaced by the AS number and "{family}" by "ipv4" or "ipv6".
+const DefaultASNURLTemplate = "https://raw.githubusercontent.com/ipverse/asn-ip/master/as/{asn}/{family}-aggregated.txt"
+
+func init() {
+	for _, set := range []struct {
+		ASNSet
+		display, color, homepage string
+	}{
+		{ASNSet{Name: "alibaba", ASNs: []int{45102}}, "Alibaba Cloud", "hi-yellow", "https://www.alibabacloud.com"},
+		{ASNSet{Name: "hetzner", ASNs: []int{24940}}, "Hetzner", "red", "https://www.hetzner.com"},
+		{ASNSet{Name: "ovh", ASNs: []int{16276}}, "OVHcloud", "hi-blue", "https://www.ovhcloud.com"},
+		{ASNSet{Name: "scaleway", ASNs: []int{12876}}, "Scaleway", "magenta", "https://www.scaleway.com"},
+		{ASNSet{Name: "contabo", ASNs: []int{51167, 40021, 141995}}, "Contabo", "cyan", "https://contabo.com"},
+	} {
+		p := set.Provider()
+		p.DisplayName, p.Category, p.Color, p.Homepage = set.display, CategoryCloud, set.color, set.homepage
+		p.License = LicenseUnstated
+		Register(p)
+	}
+}
+
+// ASNSet describes a provider made up of everything announced by one or more
+// autonomous systems. It suits hosters that publish no range list of their own.
+type ASNSet struct {
+	Name        string
+	ASNs        []int
+	URLTemplate string // defaults to DefaultASNURLTemplate
+}
+
+// Provider builds a registrable Provider for the set. Every saved prefix
+// records the AS number it was announced by.
+func (a ASNSet) Provider() Provider {
+	return Provider{
+		Name: a.Name,
+		URL:  a.url(a.firstASN(), "ipv4"),
+		Update: func(dataDir string) error {
+			ipRange, err := a.fetch()
+			if err != nil {
+				return err
+			}
+			return Save(a.Name, ipRange, dataDir)
+		},
+	}
+}
+
+// fetch downloads the IPv4 and IPv6 lists of every AS in the set and merges
+// them, tagging each prefix with its AS number. The first AS wins for a
+// prefix announced by several members.
+func (a ASNSet) fetch() (*IPRange, error) {
+	if len(a.ASNs) == 0 {
+		return nil, fmt.Errorf("%s: no AS numbers configured", a.Name)
+	}
+
+	ipRange := &IPRange{}
+	for _, asn := range a.ASNs {
+		for _, family := range []string{"ipv4", "ipv6"} {
+			body, err := Fetch(a.url(asn, family))
+			if err != nil {
+				return nil, fmt.Errorf("fetching %s AS%d %s ranges: %w", a.Name, asn, family, err)
+			}
+
+			for _, cidr := range parseCommentedCIDRs(string(body)) {
+				if _, dup := ipRange.Meta[cidr]; dup {
+					continue
+				}
+				if ClassifyCIDR(cidr) {
+					ipRange.IPv6 = append(ipRange.IPv6, cidr)
+				} else {
+					ipRange.IPv4 = append(ipRange.IPv4, cidr)
+				}
+				ipRange.setMeta(cidr, PrefixMeta{ASN: asn})
+			}
+		}
+	}
+
+	return ipRange, nil
+}
+
+// url expands the URL template for one AS number and address family.
+func (a ASNSet) url(asn int, family string) string {
+	tmpl := a.URLTemplate
+	if tmpl == "" {
+		tmpl = DefaultASNURLTemplate
+	}
+	return strings.NewReplacer("{asn}", strconv.Itoa(asn), "{family}", family).Replace(tmpl)
+}
+
+func (a ASNSet) firstASN() int {
+	if len(a.ASNs) == 0 {
+		return 0
+	}
+	return a.ASNs[0]
+}
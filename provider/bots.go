@@ -0,0 +1,34 @@
+package provider
+
+// Crawler operators that publish their ranges in Google's JSON prefix format.
+// Anthropic's crawler (ClaudeBot) is not listed: Anthropic documents only its
+// API addresses, and labelling those as a crawler would be wrong.
+func init() {
+	for _, bot := range []struct {
+		name, url, display, color, homepage string
+	}{
+		{"bingbot", "https://www.bing.com/toolbox/bingbot.json", "Bingbot", "hi-cyan",
+			"https://www.bing.com/webmasters/help/how-to-verify-bingbot-3905dc26"},
+		{"applebot", "https://search.developer.apple.com/applebot.json", "Applebot", "hi-white",
+			"https://support.apple.com/en-us/119829"},
+		{"duckduckbot", "https://duckduckgo.com/duckduckbot.json", "DuckDuckBot", "hi-red",
+			"https://duckduckgo.com/duckduckgo-help-pages/results/duckduckbot"},
+		{"perplexitybot", "https://www.perplexity.com/perplexitybot.json", "PerplexityBot", "hi-green",
+			"https://docs.perplexity.ai/guides/bots"},
+		{"perplexity-user", "https://www.perplexity.com/perplexity-user.json", "Perplexity-User", "hi-green",
+			"https://docs.perplexity.ai/guides/bots"},
+		{"commoncrawl", "https://index.commoncrawl.org/ccbot.json", "Common Crawl CCBot", "yellow",
+			"https://commoncrawl.org/ccbot"},
+	} {
+		Register(Provider{
+			Name:        bot.name,
+			URL:         bot.url,
+			Parse:       ParseJSONPrefixes,
+			DisplayName: bot.display,
+			Category:    CategoryBot,
+			Color:       bot.color,
+			Homepage:    bot.homepage,
+			License:     LicenseUnstated,
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package provider
+
+import "sort"
+
+// Provider categories. A category tells what a match means for the request:
+// who operates the address and whether the real client is behind it.
+const (
+	// CategoryCloud covers hosting and cloud platforms: the address runs
+	// someone's workload.
+	CategoryCloud = "cloud"
+	// CategoryCDN covers CDN and edge networks. A request from one of their
+	// ranges was proxied, so the client address is in the forwarding headers.
+	CategoryCDN = "cdn"
+	// CategoryBot covers the published ranges of web crawlers and AI agents,
+	// so a WAF can answer "is this a legitimate crawler?" in one place.
+	CategoryBot = "bot"
+	// CategoryCI covers build runners and webhook senders.
+	CategoryCI = "ci"
+	// CategoryProxy covers anonymizing networks, privacy relays and enterprise
+	// egress gateways. Their addresses are shared by many unrelated people, so
+	// a request from one is a proxied user rather than a cloud-hosted bot.
+	CategoryProxy = "proxy"
+	// CategoryAI covers AI vendors' API and egress ranges.
+	CategoryAI = "ai"
+	// CategoryLocal marks the local inventory (see RegisterInventory).
+	CategoryLocal = "local"
+)
+
+// Categories returns the distinct categories of the registered providers in
+// alphabetical order.
+func Categories() []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, p := range Registry {
+		if p.Category != "" && !seen[p.Category] {
+			seen[p.Category] = true
+			out = append(out, p.Category)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ByCategory returns the registered providers in any of the given categories,
+// in registry order. With no categories it returns the whole registry.
+func ByCategory(categories ...string) []Provider {
+	if len(categories) == 0 {
+		return Registry
+	}
+	want := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		want[c] = true
+	}
+	var out []Provider
+	for _, p := range Registry {
+		if want[p.Category] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// bunnyEdgeServerURLs are Bunny CDN's IPv4 and IPv6 edge server lists.
+var bunnyEdgeServerURLs = []string{
+	"https://bunnycdn.com/api/system/edgeserverlist",
+	"https://bunnycdn.com/api/system/edgeserverlist/ipv6",
+}
+
+func init() {
+	Register(Provider{
+		Name:        "fastly",
+		URL:         "https://api.fastly.com/public-ip-list",
+		Parse:       parseFastly,
+		DisplayName: "Fastly",
+		Category:    CategoryCDN,
+		Color:       "red",
+		Homepage:    "https://www.fastly.com/documentation/reference/api/utils/public-ip-list/",
+		License:     LicenseUnstated,
+	})
+
+	// Akamai publishes no range list; its edge network is announced by these ASNs.
+	akamai := ASNSet{Name: "akamai", ASNs: []int{20940, 16625}}.Provider()
+	akamai.DisplayName, akamai.Category, akamai.Color = "Akamai", CategoryCDN, "hi-blue"
+	akamai.Homepage = "https://www.akamai.com"
+	akamai.License = LicenseUnstated
+	Register(akamai)
+
+	Register(Provider{
+		Name:        "bunnycdn",
+		URL:         bunnyEdgeServerURLs[0],
+		Parse:       parseBunnyEdgeServers,
+		Update:      updateBunnyCDN,
+		DisplayName: "Bunny CDN",
+		Category:    CategoryCDN,
+		Color:       "hi-yellow",
+		Homepage:    "https://bunny.net",
+		License:     LicenseUnstated,
+	})
+}
+
+// parseFastly parses Fastly's public-ip-list JSON.
+func parseFastly(data []byte) (*IPRange, error) {
+	var result struct {
+		Addresses     []string `json:"addresses"`
+		IPv6Addresses []string `json:"ipv6_addresses"`
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("parsing Fastly data: %w", err)
+	}
+
+	return &IPRange{
+		IPv4: result.Addresses,
+		IPv6: result.IPv6Addresses,
+	}, nil
+}
+
+// bunnyXMLString matches the entries of the XML form of the edge server list.
+var bunnyXMLString = regexp.MustCompile(`<string>\s*([^<\s]+)\s*</string>`)
+
+// parseBunnyEdgeServers parses a Bunny CDN edge server list: a JSON array of
+// bare IP addresses, or the XML form the API returns by default. Every address
+// becomes a host prefix.
+func parseBunnyEdgeServers(data []byte) (*IPRange, error) {
+	var ips []string
+	if err := json.Unmarshal(data, &ips); err != nil {
+		for _, m := range bunnyXMLString.FindAllSubmatch(data, -1) {
+			ips = append(ips, string(m[1]))
+		}
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("parsing Bunny CDN edge server list: %w", err)
+		}
+	}
+
+	ipRange := &IPRange{}
+	for _, ip := range ips {
+		cidr, err := normalizeCIDR(ip)
+		if err != nil {
+			continue // Save drops anything invalid anyway
+		}
+		if strings.Contains(cidr, ":") {
+			ipRange.IPv6 = append(ipRange.IPv6, cidr)
+		} else {
+			ipRange.IPv4 = append(ipRange.IPv4, cidr)
+		}
+	}
+	return ipRange, nil
+}
+
+// updateBunnyCDN fetches the IPv4 and IPv6 edge server lists into one provider.
+func updateBunnyCDN(dataDir string) error {
+	ipRange, err := fetchAndMerge(bunnyEdgeServerURLs, parseBunnyEdgeServers)
+	if err != nil {
+		return fmt.Errorf("bunnycdn: %w", err)
+	}
+	return Save("bunnycdn", ipRange, dataDir)
+}
@@ -7,9 +7,14 @@ import (
 
 func init() {
 	Register(Provider{
-		Name:  "cloudflare",
-		URL:   "https://api.cloudflare.com/client/v4/ips",
-		Parse: parseCloudflare,
+		Name:        "cloudflare",
+		URL:         "https://api.cloudflare.com/client/v4/ips",
+		Parse:       parseCloudflare,
+		DisplayName: "Cloudflare",
+		Category:    CategoryCDN,
+		Color:       "hi-red on yellow",
+		Homepage:    "https://www.cloudflare.com/ips/",
+		License:     LicenseUnstated,
 	})
 }
 
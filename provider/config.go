@@ -0,0 +1,305 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds user-defined providers. It lives under the `providers:` key of
+// the shared config file (the same file the reputation and Shodan settings
+// use), so niche hosters and SaaS vendors can be tracked without forking.
+// The `inventory:` key points at a local CIDR inventory (see LoadInventory)
+// and `priority:` ranks providers whose ranges overlap.
+type Config struct {
+	Providers []ProviderConfig `yaml:"providers"`
+	Inventory string           `yaml:"inventory"` // optional local CIDR inventory file
+	Priority  []string         `yaml:"priority"`  // providers that win overlaps, see SetPriority
+}
+
+// ProviderConfig declares a single provider in the config file.
+type ProviderConfig struct {
+	Name   string   `yaml:"name"`
+	URL    string   `yaml:"url"`
+	URLs   []string `yaml:"urls"`   // several lists merged into one provider
+	Format string   `yaml:"format"` // text, commented, csv, json, geofeed, asn
+	Column int      `yaml:"column"` // csv: zero-based column holding the CIDR
+	Path   string   `yaml:"path"`   // json: dotted field path to the CIDRs
+	Expr   string   `yaml:"expr"`   // set expression over other providers, instead of a url
+
+	// Optional descriptors, see the matching Provider fields.
+	DisplayName string `yaml:"display_name"`
+	Category    string `yaml:"category"`
+	Color       string `yaml:"color"`
+	Homepage    string `yaml:"homepage"`
+	License     string `yaml:"license"`
+
+	// asn: AS numbers whose announced prefixes make up the provider, and an
+	// optional URL template (see DefaultASNURLTemplate) used instead of url.
+	ASNs        []int  `yaml:"asns"`
+	URLTemplate string `yaml:"url_template"`
+}
+
+// Supported values for ProviderConfig.Format.
+const (
+	FormatText      = "text"
+	FormatCommented = "commented"
+	FormatCSV       = "csv"
+	FormatJSON      = "json"
+	FormatGeofeed   = "geofeed"
+	FormatASN       = "asn"
+)
+
+// providerNameRegex restricts provider names to values that are safe to use
+// as a directory name under the data directory.
+var providerNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// LoadConfig reads user-defined providers from path. When path is empty the
+// default location is used. A missing file is not an error: it simply means
+// no extra providers are declared.
+func LoadConfig(path string) (Config, error) {
+	if path == "" {
+		path = DefaultConfigPath()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Config{}, nil
+		}
+		return Config{}, fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// DefaultConfigPath returns the shared config file location, matching the
+// reputation and shodan packages so a single file holds all settings. It
+// honors the IP2CP_REPUTATION_CONFIG override and XDG conventions.
+func DefaultConfigPath() string {
+	if p := os.Getenv("IP2CP_REPUTATION_CONFIG"); p != "" {
+		return p
+	}
+
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "ip-to-cloudprovider", "reputation.yaml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "reputation.yaml"
+	}
+	return filepath.Join(home, ".config", "ip-to-cloudprovider", "reputation.yaml")
+}
+
+// Register validates every declared provider and adds it to the global
+// registry. Nothing is registered if any entry is invalid, so a typo in the
+// config file fails loudly instead of half-applying.
+func (c Config) Register() error {
+	providers := make([]Provider, 0, len(c.Providers))
+	seen := make(map[string]bool)
+
+	for i, pc := range c.Providers {
+		p, err := pc.provider()
+		if err != nil {
+			return fmt.Errorf("provider #%d (%s): %w", i+1, pc.Name, err)
+		}
+		if seen[p.Name] || ByName(p.Name) != nil {
+			return fmt.Errorf("provider #%d: name %q is already registered", i+1, p.Name)
+		}
+		seen[p.Name] = true
+		providers = append(providers, p)
+	}
+	if err := checkDerived(append(slices.Clone(Registry), providers...)); err != nil {
+		return err
+	}
+
+	for _, p := range providers {
+		Register(p)
+	}
+	return nil
+}
+
+// provider builds a Provider from the declaration.
+func (pc ProviderConfig) provider() (Provider, error) {
+	if !providerNameRegex.MatchString(pc.Name) {
+		return Provider{}, fmt.Errorf("invalid name %q (use lowercase letters, digits, '.', '_' or '-')", pc.Name)
+	}
+
+	if pc.Expr != "" {
+		if pc.URL != "" || len(pc.URLs) > 0 || pc.Format != "" {
+			return Provider{}, fmt.Errorf("expr cannot be combined with url or format")
+		}
+		if _, err := parseSetExpr(pc.Expr); err != nil {
+			return Provider{}, fmt.Errorf("expr: %w", err)
+		}
+		p := Provider{Name: pc.Name, Expr: pc.Expr}
+		pc.describe(&p)
+		return p, nil
+	}
+
+	if strings.ToLower(pc.Format) == FormatASN {
+		if len(pc.ASNs) == 0 {
+			return Provider{}, fmt.Errorf("asn format requires at least one AS number")
+		}
+		p := ASNSet{Name: pc.Name, ASNs: pc.ASNs, URLTemplate: pc.URLTemplate}.Provider()
+		pc.describe(&p)
+		return p, nil
+	}
+
+	urls := pc.URLs
+	if pc.URL != "" {
+		urls = append([]string{pc.URL}, urls...)
+	}
+	if len(urls) == 0 {
+		return Provider{}, fmt.Errorf("no url configured")
+	}
+
+	parse, err := pc.parser()
+	if err != nil {
+		return Provider{}, err
+	}
+
+	p := Provider{
+		Name:  pc.Name,
+		URL:   urls[0],
+		Parse: parse,
+	}
+	pc.describe(&p)
+	if len(urls) > 1 {
+		name := pc.Name
+		p.Update = func(dataDir string) error {
+			ipRange, err := fetchAndMerge(urls, parse)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			return Save(name, ipRange, dataDir)
+		}
+	}
+	return p, nil
+}
+
+// describe copies the declared descriptors onto p.
+func (pc ProviderConfig) describe(p *Provider) {
+	p.DisplayName = pc.DisplayName
+	p.Category = pc.Category
+	p.Color = pc.Color
+	p.Homepage = pc.Homepage
+	p.License = pc.License
+}
+
+// parser returns the ParseFunc for the declared format.
+func (pc ProviderConfig) parser() (ParseFunc, error) {
+	switch strings.ToLower(pc.Format) {
+	case FormatText, "":
+		return ParsePlainTextCIDRs, nil
+	case FormatCommented:
+		return ParseCommentedText, nil
+	case FormatCSV:
+		if pc.Column < 0 {
+			return nil, fmt.Errorf("csv column must not be negative")
+		}
+		column := pc.Column
+		return func(data []byte) (*IPRange, error) { return parseCSVColumn(data, column) }, nil
+	case FormatJSON:
+		if pc.Path == "" {
+			return nil, fmt.Errorf("json format requires a path")
+		}
+		path := strings.Split(pc.Path, ".")
+		return func(data []byte) (*IPRange, error) { return parseJSONPath(data, path) }, nil
+	case FormatGeofeed:
+		return ParseGeofeed, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", pc.Format)
+}
+
+// fetchAndMerge downloads several lists with the same parser and merges them
+// into a single deduplicated IPRange.
+func fetchAndMerge(urls []string, parse ParseFunc) (*IPRange, error) {
+	merged := &IPRange{}
+	for _, url := range urls {
+		body, err := Fetch(url)
+		if err != nil {
+			return nil, err
+		}
+		ipRange, err := parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", url, err)
+		}
+		appendUnique(merged, ipRange)
+	}
+	return merged, nil
+}
+
+// parseCSVColumn reads CIDRs from one column of a CSV file. Comment lines (#)
+// are skipped, and header rows fall out later when Save validates the CIDRs.
+func parseCSVColumn(data []byte, column int) (*IPRange, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.LazyQuotes = true
+
+	var cidrs []string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing CSV: %w", err)
+		}
+		if column < len(record) {
+			if cidr := strings.TrimSpace(record[column]); cidr != "" {
+				cidrs = append(cidrs, cidr)
+			}
+		}
+	}
+
+	return splitIPv4v6(cidrs), nil
+}
+
+// parseJSONPath collects the strings found at a dotted field path. Arrays are
+// traversed transparently at any level, so "prefixes.ipv4Prefix" reads every
+// ipv4Prefix of a "prefixes" array and "result.ipv4_cidrs" reads a string array.
+func parseJSONPath(data []byte, path []string) (*IPRange, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing JSON: %w", err)
+	}
+	return splitIPv4v6(collectJSONStrings(doc, path)), nil
+}
+
+func collectJSONStrings(v interface{}, path []string) []string {
+	switch v := v.(type) {
+	case []interface{}:
+		var out []string
+		for _, item := range v {
+			out = append(out, collectJSONStrings(item, path)...)
+		}
+		return out
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return nil
+		}
+		return collectJSONStrings(v[path[0]], path[1:])
+	case string:
+		if len(path) == 0 {
+			return []string{strings.TrimSpace(v)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_MissingFileIsEmpty(t *testing.T) {
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), "does-not-exist.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Providers)
+}
+
+func TestLoadConfig_FromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reputation.yaml")
+	content := `
+dnsbls:
+  - name: ignored-here
+    zone: one.example
+priority: [googlebot, google]
+providers:
+  - name: examplehost
+    url: https://example.com/ranges.txt
+    format: commented
+    category: cloud
+    color: hi-magenta on black
+    display_name: Example Host
+    homepage: https://example.com/
+    license: CC-BY-4.0
+  - name: examplesaas
+    urls:
+      - https://example.com/a.json
+      - https://example.com/b.json
+    format: json
+    path: prefixes.ipv4Prefix
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Providers, 2)
+	assert.Equal(t, []string{"googlebot", "google"}, cfg.Priority)
+	assert.Equal(t, "examplehost", cfg.Providers[0].Name)
+	assert.Equal(t, "hi-magenta on black", cfg.Providers[0].Color)
+	assert.Equal(t, "Example Host", cfg.Providers[0].DisplayName)
+	assert.Equal(t, "https://example.com/", cfg.Providers[0].Homepage)
+	assert.Equal(t, "CC-BY-4.0", cfg.Providers[0].License)
+	assert.Equal(t, []string{"https://example.com/a.json", "https://example.com/b.json"}, cfg.Providers[1].URLs)
+}
+
+func TestConfigRegister(t *testing.T) {
+	t.Run("adds valid providers", func(t *testing.T) {
+		withRegistry(t, []Provider{{Name: "amazon"}}, nil)
+
+		cfg := Config{Providers: []ProviderConfig{
+			{Name: "examplehost", URL: "https://example.com/a.txt", Category: "cloud", Color: "red",
+				DisplayName: "Example Host", Homepage: "https://example.com/", License: "CC0-1.0"},
+		}}
+		require.NoError(t, cfg.Register())
+
+		p := ByName("examplehost")
+		require.NotNil(t, p)
+		assert.Equal(t, "https://example.com/a.txt", p.URL)
+		assert.NotNil(t, p.Parse)
+		assert.Nil(t, p.Update)
+		assert.Equal(t, "cloud", p.Category)
+		assert.Equal(t, "red", p.Color)
+		assert.Equal(t, "Example Host", p.Title())
+		assert.Equal(t, "https://example.com/", p.Homepage)
+		assert.Equal(t, "CC0-1.0", p.License)
+	})
+
+	tests := []struct {
+		name        string
+		providers   []ProviderConfig
+		errContains string
+	}{
+		{"clashes with built-in", []ProviderConfig{{Name: "amazon", URL: "https://x"}}, "already registered"},
+		{"duplicate entries", []ProviderConfig{{Name: "a", URL: "https://x"}, {Name: "a", URL: "https://y"}}, "already registered"},
+		{"unsafe name", []ProviderConfig{{Name: "../etc", URL: "https://x"}}, "invalid name"},
+		{"missing url", []ProviderConfig{{Name: "a"}}, "no url"},
+		{"unknown format", []ProviderConfig{{Name: "a", URL: "https://x", Format: "xml"}}, "unknown format"},
+		{"json without path", []ProviderConfig{{Name: "a", URL: "https://x", Format: "json"}}, "requires a path"},
+		{"asn without numbers", []ProviderConfig{{Name: "a", Format: "asn"}}, "at least one AS number"},
+		{"expr with url", []ProviderConfig{{Name: "a", Expr: "amazon", URL: "https://x"}}, "cannot be combined"},
+		{"expr syntax", []ProviderConfig{{Name: "a", Expr: "amazon -"}}, "expr: unexpected end"},
+		{"expr unknown operand", []ProviderConfig{{Name: "a", Expr: "amazon - nothere"}}, `unknown provider "nothere"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			withRegistry(t, []Provider{{Name: "amazon"}}, nil)
+
+			err := Config{Providers: tc.providers}.Register()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errContains)
+			assert.Len(t, Registry, 1, "nothing is registered when any entry is invalid")
+		})
+	}
+}
+
+func TestConfigRegister_Expr(t *testing.T) {
+	withRegistry(t, []Provider{{Name: "githubactions"}, {Name: "githubhooks"}}, nil)
+
+	cfg := Config{Providers: []ProviderConfig{
+		{Name: "all-ci", Expr: "githubactions + githubhooks + myci", Category: "ci"},
+		{Name: "myci", URL: "https://example.com/ci.txt"},
+	}}
+	require.NoError(t, cfg.Register())
+
+	p := ByName("all-ci")
+	require.NotNil(t, p)
+	assert.Equal(t, "githubactions + githubhooks + myci", p.Expr)
+	assert.Equal(t, "ci", p.Category)
+	assert.Nil(t, p.Parse)
+}
+
+func TestConfigRegister_ASN(t *testing.T) {
+	withRegistry(t, nil, nil)
+
+	cfg := Config{Providers: []ProviderConfig{
+		{Name: "ionos", Format: "asn", ASNs: []int{8560}, Category: "cloud"},
+		{Name: "mirror", Format: "asn", ASNs: []int{1, 2}, URLTemplate: "https://mirror.example/{asn}/{family}.txt"},
+	}}
+	require.NoError(t, cfg.Register())
+
+	p := ByName("ionos")
+	require.NotNil(t, p)
+	assert.NotNil(t, p.Update)
+	assert.Equal(t, "cloud", p.Category)
+	assert.Contains(t, p.URL, "/as/8560/ipv4-aggregated.txt")
+
+	assert.Equal(t, "https://mirror.example/1/ipv4.txt", ByName("mirror").URL)
+}
+
+func TestConfigFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    ProviderConfig
+		input  string
+		wantV4 []string
+		wantV6 []string
+	}{
+		{
+			name:   "text",
+			cfg:    ProviderConfig{Format: "text"},
+			input:  "1.2.3.0/24\n2001:db8::/32\n",
+			wantV4: []string{"1.2.3.0/24"},
+			wantV6: []string{"2001:db8::/32"},
+		},
+		{
+			name:   "commented",
+			cfg:    ProviderConfig{Format: "commented"},
+			input:  "# header\n1.2.3.0/24\n",
+			wantV4: []string{"1.2.3.0/24"},
+		},
+		{
+			name:   "csv column",
+			cfg:    ProviderConfig{Format: "csv", Column: 1},
+			input:  "# comment\nname,cidr\nedge-1,1.2.3.0/24\nedge-2,\"2001:db8::/32\"\nshort\n",
+			wantV4: []string{"cidr", "1.2.3.0/24"},
+			wantV6: []string{"2001:db8::/32"},
+		},
+		{
+			name:   "json path through arrays",
+			cfg:    ProviderConfig{Format: "json", Path: "prefixes.ipv4Prefix"},
+			input:  `{"prefixes": [{"ipv4Prefix": "1.2.3.0/24"}, {"ipv6Prefix": "2001:db8::/32"}, {"ipv4Prefix": "5.6.0.0/16"}]}`,
+			wantV4: []string{"1.2.3.0/24", "5.6.0.0/16"},
+		},
+		{
+			name:   "json path to string array",
+			cfg:    ProviderConfig{Format: "json", Path: "result.cidrs"},
+			input:  `{"result": {"cidrs": ["1.2.3.0/24", "2001:db8::/32"]}}`,
+			wantV4: []string{"1.2.3.0/24"},
+			wantV6: []string{"2001:db8::/32"},
+		},
+		{
+			name:   "geofeed",
+			cfg:    ProviderConfig{Format: "geofeed"},
+			input:  "1.2.3.0/24,US,US-CA,San Jose,\n",
+			wantV4: []string{"1.2.3.0/24"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parse, err := tc.cfg.parser()
+			require.NoError(t, err)
+			result, err := parse([]byte(tc.input))
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantV4, result.IPv4)
+			assert.Equal(t, tc.wantV6, result.IPv6)
+		})
+	}
+
+	t.Run("invalid json", func(t *testing.T) {
+		parse, err := ProviderConfig{Format: "json", Path: "a"}.parser()
+		require.NoError(t, err)
+		_, err = parse([]byte("{broken"))
+		assert.Error(t, err)
+	})
+}
+
+func TestConfigProvider_MultipleURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			fmt.Fprint(w, "1.2.3.0/24\n5.6.0.0/16\n")
+		case "/b":
+			fmt.Fprint(w, "5.6.0.0/16\n2001:db8::/32\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p, err := ProviderConfig{Name: "multi", URLs: []string{server.URL + "/a", server.URL + "/b"}}.provider()
+	require.NoError(t, err)
+	require.NotNil(t, p.Update)
+
+	dir := t.TempDir()
+	require.NoError(t, UpdateProvider(&p, dir))
+
+	loaded, err := Load("multi", dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.0/24", "5.6.0.0/16"}, loaded.IPv4)
+	assert.Equal(t, []string{"2001:db8::/32"}, loaded.IPv6)
+
+	p, err = ProviderConfig{Name: "broken", URLs: []string{server.URL + "/a", server.URL + "/missing"}}.provider()
+	require.NoError(t, err)
+	err = UpdateProvider(&p, dir)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "broken")
+}
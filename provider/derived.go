@@ -0,0 +1,277 @@
+package provider
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BenjiTrapp/ip-to-cloudprovider/cidr"
+)
+
+// Derived providers are defined by a set expression over other providers
+// instead of a download, e.g. "amazon - cloudfront" for AWS without its
+// CDN. Operators are '+' (union), '-' (difference) and '&'
+// (intersection); '&' binds tighter than '+' and '-', which apply left to
+// right, and parentheses group. Operators must be separated from names by
+// spaces, since provider names may contain '-'. The result is computed from
+// the operands' stored data and carries no per-prefix metadata.
+
+// setExpr is a parsed set expression: either a provider name or an operator
+// applied to two subexpressions.
+type setExpr struct {
+	name        string
+	op          byte // '+', '-' or '&'
+	left, right *setExpr
+}
+
+// operands returns the provider names the expression refers to.
+func (e *setExpr) operands() []string {
+	if e.op == 0 {
+		return []string{e.name}
+	}
+	return append(e.left.operands(), e.right.operands()...)
+}
+
+// eval computes the expression as an aggregated prefix list.
+func (e *setExpr) eval(dataDir string) ([]netip.Prefix, error) {
+	if e.op == 0 {
+		ipRange, err := Load(e.name, dataDir)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s: %w", e.name, err)
+		}
+		return ipRange.Prefixes(), nil
+	}
+
+	left, err := e.left.eval(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case '+':
+		return cidr.Union(left, right), nil
+	case '-':
+		return cidr.Subtract(left, right), nil
+	default:
+		return cidr.Intersect(left, right), nil
+	}
+}
+
+// parseSetExpr parses a set expression.
+func parseSetExpr(s string) (*setExpr, error) {
+	tokens, err := tokenizeSetExpr(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	p := &setExprParser{tokens: tokens}
+	e, err := p.union()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+// tokenizeSetExpr splits an expression into names, operators and
+// parentheses. A '-' is an operator only at the start of a token.
+func tokenizeSetExpr(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.IndexByte("+-&()", c) >= 0:
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			j := i
+			for j < len(s) && strings.IndexByte(" \t+&()", s[j]) < 0 {
+				j++
+			}
+			name := s[i:j]
+			if !providerNameRegex.MatchString(name) {
+				return nil, fmt.Errorf("invalid provider name %q", name)
+			}
+			tokens = append(tokens, name)
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type setExprParser struct {
+	tokens []string
+	pos    int
+}
+
+// union parses term (('+' | '-') term)*.
+func (p *setExprParser) union() (*setExpr, error) {
+	left, err := p.intersection()
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.tokens) && (p.tokens[p.pos] == "+" || p.tokens[p.pos] == "-") {
+		op := p.tokens[p.pos][0]
+		p.pos++
+		right, err := p.intersection()
+		if err != nil {
+			return nil, err
+		}
+		left = &setExpr{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// intersection parses operand ('&' operand)*.
+func (p *setExprParser) intersection() (*setExpr, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.tokens) && p.tokens[p.pos] == "&" {
+		p.pos++
+		right, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &setExpr{op: '&', left: left, right: right}
+	}
+	return left, nil
+}
+
+// operand parses a provider name or a parenthesized expression.
+func (p *setExprParser) operand() (*setExpr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch tok {
+	case "(":
+		e, err := p.union()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return e, nil
+	case ")", "+", "-", "&":
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+	return &setExpr{name: tok}, nil
+}
+
+// Derive computes a derived provider's ranges from its operands' data.
+func Derive(p *Provider, dataDir string) (*IPRange, error) {
+	e, err := parseSetExpr(p.Expr)
+	if err != nil {
+		return nil, fmt.Errorf("provider %s: %w", p.Name, err)
+	}
+	prefixes, err := e.eval(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("deriving %s: %w", p.Name, err)
+	}
+	return rangeFromPrefixes(prefixes), nil
+}
+
+// checkDerived verifies that the expressions of derived providers parse,
+// refer only to known providers and do not depend on themselves.
+func checkDerived(providers []Provider) error {
+	_, err := derivedOrder(providers)
+	return err
+}
+
+// derivedOrder returns the derived providers in dependency order, each after
+// the derived providers it refers to, so that recomputing them in this order
+// never reads an operand's stale data. It fails like checkDerived.
+func derivedOrder(providers []Provider) ([]*Provider, error) {
+	byName := make(map[string]*Provider, len(providers))
+	for i := range providers {
+		byName[providers[i].Name] = &providers[i]
+	}
+
+	// 0: unvisited, 1: in progress, 2: done
+	state := make(map[string]int)
+	var order []*Provider
+	var visit func(p *Provider) error
+	visit = func(p *Provider) error {
+		if p.Expr == "" || state[p.Name] == 2 {
+			return nil
+		}
+		if state[p.Name] == 1 {
+			return fmt.Errorf("provider %s depends on itself", p.Name)
+		}
+		state[p.Name] = 1
+
+		e, err := parseSetExpr(p.Expr)
+		if err != nil {
+			return fmt.Errorf("provider %s: %w", p.Name, err)
+		}
+		for _, name := range e.operands() {
+			operand, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("provider %s refers to unknown provider %q", p.Name, name)
+			}
+			if err := visit(operand); err != nil {
+				return err
+			}
+		}
+		state[p.Name] = 2
+		order = append(order, p)
+		return nil
+	}
+
+	for i := range providers {
+		if err := visit(&providers[i]); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// updateDependents recomputes, in dependency order, the derived providers
+// whose saved data was computed from any of the changed providers, directly
+// or through other derived providers. Derived providers without saved data
+// are computed on load and need no refresh.
+func updateDependents(changed []string, dataDir string) error {
+	derived, err := derivedOrder(Registry)
+	if err != nil {
+		return err
+	}
+	for _, p := range derived {
+		e, err := parseSetExpr(p.Expr)
+		if err != nil {
+			return fmt.Errorf("provider %s: %w", p.Name, err)
+		}
+		if !slices.ContainsFunc(e.operands(), func(name string) bool { return slices.Contains(changed, name) }) {
+			continue
+		}
+		changed = append(changed, p.Name)
+		if _, err := os.Stat(filepath.Join(dataDir, p.Name, "ipranges.json")); err != nil {
+			continue
+		}
+		ipRange, err := Derive(p, dataDir)
+		if err != nil {
+			return err
+		}
+		if err := Save(p.Name, ipRange, dataDir); err != nil {
+			return fmt.Errorf("saving %s: %w", p.Name, err)
+		}
+	}
+	return nil
+}
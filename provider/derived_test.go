@@ -0,0 +1,224 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSetExpr(t *testing.T) {
+	tests := []struct {
+		expr        string
+		operands    []string
+		errContains string
+	}{
+		{"google - googlecloud", []string{"google", "googlecloud"}, ""},
+		{"githubactions + githubhooks + openai-gptbot", []string{"githubactions", "githubhooks", "openai-gptbot"}, ""},
+		{"(a + b) & c", []string{"a", "b", "c"}, ""},
+		{"a -b", []string{"a", "b"}, ""},
+		{"", nil, "empty expression"},
+		{"a +", nil, "unexpected end"},
+		{"a b", nil, `unexpected "b"`},
+		{"(a + b", nil, "missing ')'"},
+		{"a + )", nil, `unexpected ")"`},
+		{"a + B", nil, "invalid provider name"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			e, err := parseSetExpr(tc.expr)
+			if tc.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.operands, e.operands())
+		})
+	}
+}
+
+func TestDerive(t *testing.T) {
+	dir := t.TempDir()
+	withRegistry(t, []Provider{{Name: "a"}, {Name: "b"}, {Name: "c"}}, nil)
+
+	require.NoError(t, Save("a", &IPRange{
+		IPv4: []string{"10.0.0.0/16"},
+		IPv6: []string{"2001:db8::/32"},
+	}, dir))
+	require.NoError(t, Save("b", &IPRange{
+		IPv4: []string{"10.0.1.0/24", "10.1.0.0/16"},
+		IPv6: []string{"2001:db8:8000::/33"},
+	}, dir))
+	require.NoError(t, Save("c", &IPRange{
+		IPv4: []string{"10.1.0.0/17"},
+	}, dir))
+
+	tests := []struct {
+		expr string
+		ipv4 []string
+		ipv6 []string
+	}{
+		{
+			expr: "a - b",
+			ipv4: []string{"10.0.0.0/24", "10.0.2.0/23", "10.0.4.0/22", "10.0.8.0/21", "10.0.16.0/20", "10.0.32.0/19", "10.0.64.0/18", "10.0.128.0/17"},
+			ipv6: []string{"2001:db8::/33"},
+		},
+		{expr: "a + b", ipv4: []string{"10.0.0.0/15"}, ipv6: []string{"2001:db8::/32"}},
+		{expr: "a & b", ipv4: []string{"10.0.1.0/24"}, ipv6: []string{"2001:db8:8000::/33"}},
+		{expr: "a + b & c", ipv4: []string{"10.0.0.0/16", "10.1.0.0/17"}, ipv6: []string{"2001:db8::/32"}},
+		{expr: "(a + b) - c", ipv4: []string{"10.0.0.0/16", "10.1.128.0/17"}, ipv6: []string{"2001:db8::/32"}},
+		{expr: "c - b", ipv4: nil, ipv6: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			ipRange, err := Derive(&Provider{Name: "d", Expr: tc.expr}, dir)
+			require.NoError(t, err)
+			assert.Equal(t, tc.ipv4, ipRange.IPv4)
+			assert.Equal(t, tc.ipv6, ipRange.IPv6)
+		})
+	}
+
+	t.Run("missing operand data", func(t *testing.T) {
+		_, err := Derive(&Provider{Name: "d", Expr: "a - nodata"}, dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "loading nodata")
+	})
+}
+
+func TestDerivedProvider(t *testing.T) {
+	dir := t.TempDir()
+	withRegistry(t, []Provider{
+		{Name: "google"},
+		{Name: "googlecloud"},
+		{Name: "google-services", Expr: "google - googlecloud"},
+	}, nil)
+
+	require.NoError(t, Save("google", &IPRange{IPv4: []string{"34.0.0.0/15"}}, dir))
+	require.NoError(t, Save("googlecloud", &IPRange{IPv4: []string{"34.1.0.0/16"}}, dir))
+
+	t.Run("computed on load without a data file", func(t *testing.T) {
+		assert.True(t, HasData("google-services", dir))
+		ipRange, err := Load("google-services", dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"34.0.0.0/16"}, ipRange.IPv4)
+		assert.False(t, HasData("google-services", t.TempDir()))
+	})
+
+	t.Run("saved by UpdateProvider", func(t *testing.T) {
+		require.NoError(t, UpdateProvider(ByName("google-services"), dir))
+		_, err := os.Stat(filepath.Join(dir, "google-services", "ipranges.json"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("matched like any provider", func(t *testing.T) {
+		m := NewMatcher(dir)
+		assert.Equal(t, "google-services", m.Match("34.0.1.1"))
+		assert.Equal(t, "googlecloud", m.Match("34.1.1.1"))
+	})
+}
+
+func TestUpdateProvider_Dependents(t *testing.T) {
+	dir := t.TempDir()
+	fetched := &IPRange{IPv4: []string{"10.0.0.0/24", "10.0.1.0/24"}}
+	withRegistry(t, []Provider{
+		{Name: "all", Expr: "some + c"},
+		{Name: "some", Expr: "a - b"},
+		{Name: "a", Update: func(dataDir string) error { return Save("a", fetched, dataDir) }},
+		{Name: "b"},
+		{Name: "c"},
+		{Name: "unsaved", Expr: "a & b"},
+		{Name: "other", Expr: "b + c"},
+	}, nil)
+
+	require.NoError(t, Save("a", &IPRange{IPv4: []string{"10.0.0.0/24"}}, dir))
+	require.NoError(t, Save("b", &IPRange{IPv4: []string{"10.0.1.0/24"}}, dir))
+	require.NoError(t, Save("c", &IPRange{IPv4: []string{"192.0.2.0/24"}}, dir))
+	for _, name := range []string{"some", "all", "other"} {
+		require.NoError(t, UpdateProvider(ByName(name), dir))
+	}
+	otherInfo, err := os.Stat(filepath.Join(dir, "other", "ipranges.json"))
+	require.NoError(t, err)
+
+	fetched.IPv4 = append(fetched.IPv4, "10.0.2.0/24")
+	require.NoError(t, UpdateProvider(ByName("a"), dir))
+
+	some, err := Load("some", dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/24", "10.0.2.0/24"}, some.IPv4)
+	all, err := Load("all", dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/24", "10.0.2.0/24", "192.0.2.0/24"}, all.IPv4, "recomputed after some")
+
+	_, err = os.Stat(filepath.Join(dir, "unsaved", "ipranges.json"))
+	assert.True(t, os.IsNotExist(err), "derived providers computed on load stay unsaved")
+	info, err := os.Stat(filepath.Join(dir, "other", "ipranges.json"))
+	require.NoError(t, err)
+	assert.Equal(t, otherInfo.ModTime(), info.ModTime(), "independent derived providers are left alone")
+}
+
+func TestCheckDerived(t *testing.T) {
+	require.NoError(t, checkDerived(Registry), "built-in derived providers are valid")
+
+	order, err := derivedOrder([]Provider{{Name: "a", Expr: "b + c"}, {Name: "b", Expr: "c & d"}, {Name: "c"}, {Name: "d"}})
+	require.NoError(t, err)
+	require.Len(t, order, 2)
+	assert.Equal(t, "b", order[0].Name)
+	assert.Equal(t, "a", order[1].Name)
+
+	tests := []struct {
+		name        string
+		providers   []Provider
+		errContains string
+	}{
+		{"unknown operand", []Provider{{Name: "a", Expr: "b - c"}, {Name: "b"}}, `unknown provider "c"`},
+		{"self reference", []Provider{{Name: "a", Expr: "a + b"}, {Name: "b"}}, "depends on itself"},
+		{"cycle", []Provider{{Name: "a", Expr: "b"}, {Name: "b", Expr: "a"}}, "depends on itself"},
+		{"syntax error", []Provider{{Name: "a", Expr: "b +"}, {Name: "b"}}, "unexpected end"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkDerived(tc.providers)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errContains)
+		})
+	}
+
+	assert.NoError(t, checkDerived([]Provider{{Name: "a", Expr: "b + c"}, {Name: "b", Expr: "c & c"}, {Name: "c"}}))
+}
+
+func TestUpdateAll_DerivedLast(t *testing.T) {
+	dir := t.TempDir()
+	withRegistry(t, []Provider{
+		{Name: "narrow", Expr: "both & x"},
+		{Name: "both", Expr: "x + y"},
+		{Name: "x", Update: func(dataDir string) error {
+			return Save("x", &IPRange{IPv4: []string{"192.0.2.0/25"}}, dataDir)
+		}},
+		{Name: "y", Update: func(dataDir string) error {
+			return Save("y", &IPRange{IPv4: []string{"192.0.2.128/25"}}, dataDir)
+		}},
+	}, nil)
+
+	var order []string
+	UpdateAll(dir, func(name string, err error) {
+		require.NoError(t, err, name)
+		order = append(order, name)
+	})
+	assert.Equal(t, []string{"x", "y", "both", "narrow"}, order, "derived providers after their operands")
+
+	ipRange, err := Load("both", dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.0.2.0/24"}, ipRange.IPv4)
+
+	// narrow was saved from the fresh "both", not computed from its old data
+	require.NoError(t, os.Remove(filepath.Join(dir, "both", "ipranges.json")))
+	ipRange, err = Load("narrow", dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.0.2.0/25"}, ipRange.IPv4)
+}
@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func init() {
+	Register(Provider{
+		Name:        "linode",
+		URL:         "https://geoip.linode.com/",
+		Parse:       ParseGeofeed,
+		DisplayName: "Linode (Akamai)",
+		Category:    CategoryCloud,
+		Color:       "green",
+		Homepage:    "https://www.linode.com",
+		License:     LicenseUnstated,
+	})
+	Register(Provider{
+		Name:        "vultr",
+		URL:         "https://geofeed.constant.com/?text",
+		Parse:       ParseGeofeed,
+		DisplayName: "Vultr",
+		Category:    CategoryCloud,
+		Color:       "hi-blue",
+		Homepage:    "https://www.vultr.com",
+		License:     LicenseUnstated,
+	})
+}
+
+// ParseGeofeed parses an RFC 8805 geofeed: CSV rows of
+// "prefix,country,region,city,postal" with no header. Comment lines (#) and
+// blank lines are skipped, and trailing columns may be omitted. The country
+// (ISO 3166-1 alpha-2), region (ISO 3166-2) and city are kept as per-prefix
+// metadata, if any is given; the deprecated postal code column is ignored.
+func ParseGeofeed(data []byte) (*IPRange, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.LazyQuotes = true
+
+	ipRange := &IPRange{}
+	seen := make(map[string]bool)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing geofeed: %w", err)
+		}
+
+		cidr := geofeedField(record, 0)
+		if cidr == "" {
+			continue
+		}
+		if seen[cidr] {
+			continue
+		}
+		seen[cidr] = true
+
+		if ClassifyCIDR(cidr) {
+			ipRange.IPv6 = append(ipRange.IPv6, cidr)
+		} else {
+			ipRange.IPv4 = append(ipRange.IPv4, cidr)
+		}
+		meta := PrefixMeta{
+			Country: geofeedField(record, 1),
+			Region:  geofeedField(record, 2),
+			City:    geofeedField(record, 3),
+		}
+		if meta.Country != "" || meta.Region != "" || meta.City != "" {
+			ipRange.setMeta(cidr, meta)
+		}
+	}
+
+	return ipRange, nil
+}
+
+// geofeedField returns a trimmed column, or "" when it is missing. Some feeds
+// (e.g. DigitalOcean) write "None" for unknown locations; that is treated as
+// empty too.
+func geofeedField(record []string, i int) string {
+	if i >= len(record) {
+		return ""
+	}
+	field := strings.TrimSpace(record[i])
+	if field == "None" {
+		return ""
+	}
+	return field
+}
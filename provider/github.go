@@ -5,28 +5,60 @@ import (
 	"fmt"
 )
 
-const gitHubMetaURL = "https://api.github.com/meta"
+const (
+	gitHubMetaURL  = "https://api.github.com/meta"
+	gitHubHomepage = "https://docs.github.com/en/rest/meta/meta"
+)
 
 func init() {
-	Register(Provider{
+	RegisterSource(Source{
 		Name:  "github",
 		URL:   gitHubMetaURL,
-		Parse: parseGitHubWeb,
+		Split: splitGitHubMeta,
 	})
 	Register(Provider{
-		Name:  "githubactions",
-		URL:   gitHubMetaURL,
-		Parse: parseGitHubActions,
+		Name:        "github",
+		URL:         gitHubMetaURL,
+		Parse:       parseGitHubWeb,
+		Source:      "github",
+		DisplayName: "GitHub",
+		Category:    CategoryCloud,
+		Color:       "black on white",
+		Homepage:    gitHubHomepage,
+		License:     LicenseUnstated,
 	})
 	Register(Provider{
-		Name:  "githubhooks",
-		URL:   gitHubMetaURL,
-		Parse: parseGitHubHooks,
+		Name:        "githubactions",
+		URL:         gitHubMetaURL,
+		Parse:       parseGitHubActions,
+		Source:      "github",
+		DisplayName: "GitHub Actions",
+		Category:    CategoryCI,
+		Color:       "black on white",
+		Homepage:    gitHubHomepage,
+		License:     LicenseUnstated,
 	})
 	Register(Provider{
-		Name:  "githubpages",
-		URL:   gitHubMetaURL,
-		Parse: parseGitHubPages,
+		Name:        "githubhooks",
+		URL:         gitHubMetaURL,
+		Parse:       parseGitHubHooks,
+		Source:      "github",
+		DisplayName: "GitHub Hooks",
+		Category:    CategoryCI,
+		Color:       "black on white",
+		Homepage:    gitHubHomepage,
+		License:     LicenseUnstated,
+	})
+	Register(Provider{
+		Name:        "githubpages",
+		URL:         gitHubMetaURL,
+		Parse:       parseGitHubPages,
+		Source:      "github",
+		DisplayName: "GitHub Pages",
+		Category:    CategoryCDN,
+		Color:       "black on white",
+		Homepage:    gitHubHomepage,
+		License:     LicenseUnstated,
 	})
 }
 
@@ -90,39 +122,17 @@ func parseGitHubPages(data []byte) (*IPRange, error) {
 	return splitIPv4v6(meta.Pages), nil
 }
 
-// UpdateGitHubAll fetches the GitHub /meta endpoint once and saves all
-// sub-providers, avoiding redundant HTTP requests.
-func UpdateGitHubAll(dataDir string) error {
-	body, err := Fetch(gitHubMetaURL)
+// splitGitHubMeta parses the /meta response once and fans it out into all
+// GitHub sub-providers, avoiding redundant HTTP requests.
+func splitGitHubMeta(data []byte) (map[string]*IPRange, error) {
+	meta, err := parseGitHubMeta(data)
 	if err != nil {
-		return fmt.Errorf("fetching GitHub meta: %w", err)
-	}
-
-	parsers := map[string]ParseFunc{
-		"github":        parseGitHubWeb,
-		"githubactions": parseGitHubActions,
-		"githubhooks":   parseGitHubHooks,
-		"githubpages":   parseGitHubPages,
-	}
-
-	for name, parse := range parsers {
-		ipRange, err := parse(body)
-		if err != nil {
-			return fmt.Errorf("parsing %s: %w", name, err)
-		}
-		if err := Save(name, ipRange, dataDir); err != nil {
-			return fmt.Errorf("saving %s: %w", name, err)
-		}
-	}
-
-	return nil
-}
-
-// IsGitHubProvider returns true if the provider is one of the GitHub sub-providers.
-func IsGitHubProvider(name string) bool {
-	switch name {
-	case "github", "githubactions", "githubhooks", "githubpages":
-		return true
+		return nil, err
 	}
-	return false
+	return map[string]*IPRange{
+		"github":        splitIPv4v6(meta.Web),
+		"githubactions": splitIPv4v6(meta.Actions),
+		"githubhooks":   splitIPv4v6(meta.Hooks),
+		"githubpages":   splitIPv4v6(meta.Pages),
+	}, nil
 }
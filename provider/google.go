@@ -1,25 +1,35 @@
 package provider
 
-import (
-	"encoding/json"
-	"fmt"
-)
-
 func init() {
 	Register(Provider{
-		Name:  "google",
-		URL:   "https://www.gstatic.com/ipranges/goog.txt",
-		Parse: parseGoogleTxt,
+		Name:        "google",
+		URL:         "https://www.gstatic.com/ipranges/goog.txt",
+		Parse:       parseGoogleTxt,
+		DisplayName: "Google",
+		Category:    CategoryCloud,
+		Color:       "red",
+		Homepage:    "https://support.google.com/a/answer/10026322",
+		License:     LicenseUnstated,
 	})
 	Register(Provider{
-		Name:  "googlecloud",
-		URL:   "https://www.gstatic.com/ipranges/cloud.json",
-		Parse: parseGoogleJSON,
+		Name:        "googlecloud",
+		URL:         "https://www.gstatic.com/ipranges/cloud.json",
+		Parse:       parseGoogleJSON,
+		DisplayName: "Google Cloud",
+		Category:    CategoryCloud,
+		Color:       "red",
+		Homepage:    "https://cloud.google.com/compute/docs/faq#find_ip_range",
+		License:     LicenseUnstated,
 	})
 	Register(Provider{
-		Name:  "googlebot",
-		URL:   "https://developers.google.com/search/apis/ipranges/googlebot.json",
-		Parse: parseGoogleJSON,
+		Name:        "googlebot",
+		URL:         "https://developers.google.com/search/apis/ipranges/googlebot.json",
+		Parse:       parseGoogleJSON,
+		DisplayName: "Googlebot",
+		Category:    CategoryBot,
+		Color:       "red",
+		Homepage:    "https://developers.google.com/search/docs/crawling-indexing/verifying-googlebot",
+		License:     LicenseUnstated,
 	})
 }
 
@@ -30,26 +40,5 @@ func parseGoogleTxt(data []byte) (*IPRange, error) {
 
 // parseGoogleJSON parses Google's JSON IP range format (cloud.json, googlebot.json).
 func parseGoogleJSON(data []byte) (*IPRange, error) {
-	var result struct {
-		Prefixes []struct {
-			IPv4Prefix string `json:"ipv4Prefix"`
-			IPv6Prefix string `json:"ipv6Prefix"`
-		} `json:"prefixes"`
-	}
-
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, fmt.Errorf("parsing Google JSON data: %w", err)
-	}
-
-	ipRange := &IPRange{}
-	for _, prefix := range result.Prefixes {
-		if prefix.IPv4Prefix != "" {
-			ipRange.IPv4 = append(ipRange.IPv4, prefix.IPv4Prefix)
-		}
-		if prefix.IPv6Prefix != "" {
-			ipRange.IPv6 = append(ipRange.IPv6, prefix.IPv6Prefix)
-		}
-	}
-
-	return ipRange, nil
+	return ParseJSONPrefixes(data)
 }
@@ -0,0 +1,210 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// InventoryProviderName is the provider name under which a local inventory is
+// registered.
+const InventoryProviderName = "local"
+
+// InventoryEntry describes one prefix of the organisation's own address space.
+type InventoryEntry struct {
+	CIDR        string            `yaml:"cidr"`
+	CIDRs       []string          `yaml:"cidrs"` // several prefixes sharing the same details
+	Name        string            `yaml:"name"`
+	Owner       string            `yaml:"owner"`
+	Environment string            `yaml:"environment"`
+	Labels      map[string]string `yaml:"labels"`
+}
+
+// LoadInventory reads a local CIDR inventory. Files ending in .csv are read as
+// CSV with a header row naming the columns (cidr, name, owner, environment,
+// labels; labels as "key=value" pairs separated by ';'). Anything else is read
+// as a YAML list of InventoryEntry. Bare IP addresses are accepted as host
+// prefixes.
+func LoadInventory(path string) (*IPRange, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading inventory %s: %w", path, err)
+	}
+
+	var entries []InventoryEntry
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		entries, err = parseInventoryCSV(data)
+	} else {
+		err = yaml.Unmarshal(data, &entries)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing inventory %s: %w", path, err)
+	}
+
+	ipRange, err := inventoryRange(entries)
+	if err != nil {
+		return nil, fmt.Errorf("inventory %s: %w", path, err)
+	}
+	return ipRange, nil
+}
+
+// RegisterInventory loads the inventory at path and registers it as the
+// "local" provider. Lookups check it before any provider, so the
+// organisation's own address space wins even over more specific cloud ranges.
+// Local data is read from the file only: it is never updated, saved to the
+// data directory or embedded.
+func RegisterInventory(path string) error {
+	if ByName(InventoryProviderName) != nil {
+		return fmt.Errorf("provider %q is already registered", InventoryProviderName)
+	}
+
+	ipRange, err := LoadInventory(path)
+	if err != nil {
+		return err
+	}
+
+	p := Provider{
+		Name:        InventoryProviderName,
+		URL:         path,
+		Load:        func() (*IPRange, error) { return ipRange, nil },
+		DisplayName: "Local inventory",
+		Category:    CategoryLocal,
+		Color:       "hi-green",
+	}
+	Registry = append([]Provider{p}, Registry...)
+	return nil
+}
+
+// inventoryRange converts inventory entries into an IPRange carrying each
+// entry's details as prefix metadata. The first entry for a prefix wins.
+func inventoryRange(entries []InventoryEntry) (*IPRange, error) {
+	result := &IPRange{}
+	seen := make(map[string]bool)
+
+	for i, e := range entries {
+		cidrs := e.CIDRs
+		if e.CIDR != "" {
+			cidrs = append([]string{e.CIDR}, cidrs...)
+		}
+		if len(cidrs) == 0 {
+			return nil, fmt.Errorf("entry #%d has no cidr", i+1)
+		}
+
+		for _, raw := range cidrs {
+			cidr, err := normalizeCIDR(raw)
+			if err != nil {
+				return nil, fmt.Errorf("entry #%d: %w", i+1, err)
+			}
+			if seen[cidr] {
+				continue
+			}
+			seen[cidr] = true
+
+			if strings.Contains(cidr, ":") {
+				result.IPv6 = append(result.IPv6, cidr)
+			} else {
+				result.IPv4 = append(result.IPv4, cidr)
+			}
+			if e.Name != "" || e.Owner != "" || e.Environment != "" || len(e.Labels) > 0 {
+				result.setMeta(cidr, PrefixMeta{
+					Name:        e.Name,
+					Owner:       e.Owner,
+					Environment: e.Environment,
+					Labels:      e.Labels,
+				})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// parseInventoryCSV reads inventory entries from CSV. The header row decides
+// which column holds which field; unknown columns are ignored.
+func parseInventoryCSV(data []byte) ([]InventoryEntry, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["cidr"]; !ok {
+		return nil, fmt.Errorf("header row has no cidr column")
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var entries []InventoryEntry
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, InventoryEntry{
+			CIDR:        field(record, "cidr"),
+			Name:        field(record, "name"),
+			Owner:       field(record, "owner"),
+			Environment: field(record, "environment"),
+			Labels:      parseInventoryLabels(field(record, "labels")),
+		})
+	}
+	return entries, nil
+}
+
+// parseInventoryLabels parses "key=value;key=value". A label without '=' is
+// kept with an empty value.
+func parseInventoryLabels(s string) map[string]string {
+	if s == "" {
+		return nil
+	}
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(s, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		labels[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return labels
+}
+
+// SortedLabels renders labels as "key=value" strings in key order.
+func SortedLabels(labels map[string]string) []string {
+	out := make([]string, 0, len(labels))
+	for k, v := range labels {
+		if v == "" {
+			out = append(out, k)
+		} else {
+			out = append(out, k+"="+v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
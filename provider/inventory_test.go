@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeInventory(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadInventory(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "inventory.yaml",
+			content: `
+- cidr: 10.20.0.0/16
+  name: corp-vpn
+  owner: team-x
+  environment: prod
+  labels: {site: fra1}
+- cidrs: [203.0.113.7, "2001:db8::/48"]
+  owner: team-y
+- cidr: 10.20.0.0/16
+  name: duplicate-is-ignored
+`,
+		},
+		{
+			name: "csv",
+			file: "inventory.CSV",
+			content: `# our address space
+cidr,name,owner,environment,labels
+10.20.0.0/16,corp-vpn,team-x,prod,site=fra1
+203.0.113.7,,team-y,,
+2001:db8::/48,,team-y,,
+10.20.0.0/16,duplicate-is-ignored,,,
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ipRange, err := LoadInventory(writeInventory(t, tc.file, tc.content))
+			require.NoError(t, err)
+			assert.Equal(t, []string{"10.20.0.0/16", "203.0.113.7/32"}, ipRange.IPv4)
+			assert.Equal(t, []string{"2001:db8::/48"}, ipRange.IPv6)
+
+			meta := ipRange.MetaFor("10.20.0.0/16")
+			require.NotNil(t, meta)
+			assert.Equal(t, "corp-vpn", meta.Name)
+			assert.Equal(t, "team-x", meta.Owner)
+			assert.Equal(t, "prod", meta.Environment)
+			assert.Equal(t, map[string]string{"site": "fra1"}, meta.Labels)
+			assert.Equal(t, "team-y", ipRange.MetaFor("2001:db8::/48").Owner)
+		})
+	}
+}
+
+func TestLoadInventory_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		errContains string
+	}{
+		{"invalid cidr", "inv.yaml", "- cidr: 10.0.0.0/33\n", "invalid cidr"},
+		{"entry without cidr", "inv.yaml", "- name: nothing\n", "has no cidr"},
+		{"csv without cidr column", "inv.csv", "name,owner\na,b\n", "no cidr column"},
+		{"broken yaml", "inv.yaml", "cidr: [\n", "parsing inventory"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadInventory(writeInventory(t, tc.file, tc.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errContains)
+		})
+	}
+
+	_, err := LoadInventory(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestRegisterInventory(t *testing.T) {
+	withRegistry(t, []Provider{{Name: "amazon", Update: func(string) error {
+		t.Fatal("cloud provider should not be reached")
+		return nil
+	}}}, nil)
+
+	dir := t.TempDir()
+	require.NoError(t, Save("amazon", &IPRange{IPv4: []string{"10.0.0.0/8", "10.20.5.0/24"}}, dir))
+
+	path := writeInventory(t, "inventory.yaml", "- cidr: 10.20.0.0/16\n  name: corp-vpn\n  owner: team-x\n")
+	require.NoError(t, RegisterInventory(path))
+	assert.Equal(t, InventoryProviderName, Registry[0].Name, "local provider takes precedence")
+
+	assert.True(t, HasData(InventoryProviderName, dir))
+	assert.Equal(t, InventoryProviderName, CheckIP("10.20.1.1", dir))
+	assert.Equal(t, "amazon", CheckIP("10.30.1.1", dir))
+	assert.Equal(t, InventoryProviderName, CheckIP("10.20.5.1", dir), "wins over a more specific cloud prefix")
+
+	orig := Priority
+	t.Cleanup(func() { Priority = orig })
+	require.NoError(t, SetPriority([]string{"amazon"}))
+	assert.Equal(t, InventoryProviderName, CheckIP("10.20.5.1", dir), "wins whatever the priority list")
+	Priority = orig
+
+	result := NewMatcher(dir).Lookup("10.20.1.1")
+	assert.Equal(t, InventoryProviderName, result.Provider)
+	require.NotNil(t, result.Meta)
+	assert.Equal(t, "team-x", result.Meta.Owner)
+
+	err := UpdateProvider(ByName(InventoryProviderName), dir)
+	assert.ErrorContains(t, err, "never updated")
+	Registry[1].Update = nil // UpdateAll would otherwise reach the stub above
+	var reported []string
+	UpdateAll(dir, func(name string, err error) { reported = append(reported, name) })
+	assert.NotContains(t, reported, InventoryProviderName)
+
+	_, err = os.Stat(filepath.Join(dir, InventoryProviderName))
+	assert.True(t, os.IsNotExist(err), "local data is never written to the data directory")
+
+	assert.ErrorContains(t, RegisterInventory(path), "already registered")
+}
@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// m365EndpointsURL is the worldwide instance of the Microsoft 365 endpoint web
+// service. The service requires a client request ID; a fixed one is fine.
+const m365EndpointsURL = "https://endpoints.office.com/endpoints/worldwide?clientrequestid=b10c5ed1-bad1-445f-b386-b919946339a7"
+
+func init() {
+	Register(Provider{
+		Name:        "m365",
+		URL:         m365EndpointsURL,
+		Parse:       parseM365,
+		DisplayName: "Microsoft 365",
+		Category:    CategoryCloud,
+		Color:       "hi-blue",
+		Homepage:    "https://learn.microsoft.com/en-us/microsoft-365/enterprise/microsoft-365-ip-web-service",
+		License:     LicenseUnstated,
+	})
+}
+
+// parseM365 parses the Microsoft 365 endpoint web service response. Each
+// endpoint set lists its IPs with a service area (Exchange, SharePoint, Skype
+// for Teams, Common) and a network category (Optimize, Allow, Default). Each
+// pair is kept as one tag, e.g. "Exchange/optimize", so a prefix shared by
+// several endpoint sets still tells which category applies to which service
+// area, as proxy bypass rules need.
+func parseM365(data []byte) (*IPRange, error) {
+	var endpoints []struct {
+		ServiceArea string   `json:"serviceArea"`
+		Category    string   `json:"category"`
+		IPs         []string `json:"ips"`
+	}
+	if err := json.Unmarshal(data, &endpoints); err != nil {
+		return nil, fmt.Errorf("parsing Microsoft 365 endpoints: %w", err)
+	}
+
+	ipRange := &IPRange{}
+	for _, e := range endpoints {
+		tag := e.ServiceArea
+		if e.Category != "" {
+			if tag != "" {
+				tag += "/"
+			}
+			tag += strings.ToLower(e.Category)
+		}
+
+		for _, cidr := range e.IPs {
+			cidr = strings.TrimSpace(cidr)
+			meta, seen := ipRange.Meta[cidr]
+			if !seen {
+				if strings.Contains(cidr, ":") {
+					ipRange.IPv6 = append(ipRange.IPv6, cidr)
+				} else {
+					ipRange.IPv4 = append(ipRange.IPv4, cidr)
+				}
+			}
+			if tag != "" {
+				meta.Tags = appendTag(meta.Tags, tag)
+			}
+			ipRange.setMeta(cidr, meta)
+		}
+	}
+	return ipRange, nil
+}
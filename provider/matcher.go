@@ -16,36 +16,43 @@ type Matcher struct {
 }
 
 type matcherEntry struct {
-	name string
-	nets []*net.IPNet
+	name        string
+	displayName string
+	category    string
+	nets        []*net.IPNet
+	meta        []*PrefixMeta // aligned with nets; nil where the prefix has none
 }
 
 // NewMatcher loads all provider IP ranges from disk and pre-parses CIDR
 // networks for fast lookup. Providers that fail to load are silently skipped.
 func NewMatcher(dataDir string) *Matcher {
+	return NewMatcherFor(dataDir, Registry)
+}
+
+// NewMatcherFor is like NewMatcher but only matches against the given
+// providers (e.g. the result of ByCategory). Overlaps are resolved by prefix
+// length and then by Priority, whatever the order of providers.
+func NewMatcherFor(dataDir string, providers []Provider) *Matcher {
 	m := &Matcher{}
-	for _, p := range Registry {
+	for _, p := range SortByPriority(providers) {
 		ipRange, err := Load(p.Name, dataDir)
 		if err != nil {
 			continue
 		}
 
-		var nets []*net.IPNet
-		for _, cidr := range ipRange.IPv4 {
-			_, ipNet, err := net.ParseCIDR(cidr)
-			if err == nil {
-				nets = append(nets, ipNet)
-			}
-		}
-		for _, cidr := range ipRange.IPv6 {
-			_, ipNet, err := net.ParseCIDR(cidr)
-			if err == nil {
-				nets = append(nets, ipNet)
+		entry := matcherEntry{name: p.Name, displayName: p.Title(), category: p.Category}
+		for _, cidrs := range [][]string{ipRange.IPv4, ipRange.IPv6} {
+			for _, cidr := range cidrs {
+				_, ipNet, err := net.ParseCIDR(cidr)
+				if err == nil {
+					entry.nets = append(entry.nets, ipNet)
+					entry.meta = append(entry.meta, ipRange.MetaFor(cidr))
+				}
 			}
 		}
 
-		if len(nets) > 0 {
-			m.entries = append(m.entries, matcherEntry{name: p.Name, nets: nets})
+		if len(entry.nets) > 0 {
+			m.entries = append(m.entries, entry)
 			m.loaded++
 		}
 	}
@@ -59,25 +66,53 @@ func (m *Matcher) Loaded() int {
 
 // Match returns the provider name for the given IP, or empty string if not found.
 func (m *Matcher) Match(ip string) string {
+	return m.Lookup(ip).Provider
+}
+
+// Lookup returns the full match result for the given IP, including any
+// metadata the provider publishes for the matching prefix. The local
+// inventory wins over every provider; otherwise the most specific matching
+// prefix wins. Entries are in priority order, so among equally specific
+// prefixes the higher-ranked provider wins.
+func (m *Matcher) Lookup(ip string) MatchResult {
+	result := MatchResult{IP: ip}
 	parsedIP := net.ParseIP(ip)
 	if parsedIP == nil {
-		return ""
+		return result
 	}
+	best := -1
+	bestLocal := false
 	for _, entry := range m.entries {
-		for _, ipNet := range entry.nets {
-			if ipNet.Contains(parsedIP) {
-				return entry.name
+		local := entry.name == InventoryProviderName
+		if bestLocal && !local {
+			continue
+		}
+		for i, ipNet := range entry.nets {
+			if !ipNet.Contains(parsedIP) {
+				continue
+			}
+			if ones, _ := ipNet.Mask.Size(); ones > best || local && !bestLocal {
+				best = ones
+				bestLocal = local
+				result.Provider = entry.name
+				result.DisplayName = entry.displayName
+				result.Category = entry.category
+				result.Match = true
+				result.Meta = entry.meta[i]
 			}
 		}
 	}
-	return ""
+	return result
 }
 
 // MatchResult holds the result of an IP lookup.
 type MatchResult struct {
-	IP       string `json:"ip"`
-	Provider string `json:"provider,omitempty"`
-	Match    bool   `json:"match"`
+	IP          string      `json:"ip"`
+	Provider    string      `json:"provider,omitempty"`
+	DisplayName string      `json:"display_name,omitempty"`
+	Category    string      `json:"category,omitempty"`
+	Match       bool        `json:"match"`
+	Meta        *PrefixMeta `json:"meta,omitempty"`
 }
 
 // MatchAll checks multiple IPs and returns results in order.
@@ -88,8 +123,7 @@ func (m *Matcher) MatchAll(ips []string) []MatchResult {
 	if len(ips) < concurrencyThreshold {
 		// Sequential for small batches (avoids goroutine overhead)
 		for i, ip := range ips {
-			name := m.Match(ip)
-			results[i] = MatchResult{IP: ip, Provider: name, Match: name != ""}
+			results[i] = m.Lookup(ip)
 		}
 		return results
 	}
@@ -100,8 +134,7 @@ func (m *Matcher) MatchAll(ips []string) []MatchResult {
 		wg.Add(1)
 		go func(idx int, addr string) {
 			defer wg.Done()
-			name := m.Match(addr)
-			results[idx] = MatchResult{IP: addr, Provider: name, Match: name != ""}
+			results[idx] = m.Lookup(addr)
 		}(i, ip)
 	}
 	wg.Wait()
@@ -60,6 +60,20 @@ func TestMatcher_Match(t *testing.T) {
 	}
 }
 
+func TestNewMatcherFor(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, Save("amazon", &IPRange{IPv4: []string{"13.224.0.0/14"}}, dir))
+	require.NoError(t, Save("cloudflare", &IPRange{IPv4: []string{"198.41.128.0/17"}}, dir))
+
+	m := NewMatcherFor(dir, []Provider{*ByName("cloudflare")})
+	assert.Equal(t, "", m.Match("13.224.1.1"))
+
+	r := m.Lookup("198.41.200.1")
+	assert.Equal(t, "cloudflare", r.Provider)
+	assert.Equal(t, "Cloudflare", r.DisplayName)
+	assert.Equal(t, CategoryCDN, r.Category)
+}
+
 func TestMatcher_MatchAll(t *testing.T) {
 	dir := t.TempDir()
 
@@ -91,6 +105,79 @@ func TestMatcher_MatchAll(t *testing.T) {
 	assert.False(t, results[2].Match)
 }
 
+func TestMatcher_Lookup_Meta(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, Save("oracle", &IPRange{
+		IPv4: []string{"129.146.0.0/21", "130.61.0.0/16"},
+		Meta: map[string]PrefixMeta{
+			"129.146.0.0/21": {Region: "us-phoenix-1", Tags: []string{"OCI"}},
+		},
+	}, dir))
+
+	m := NewMatcher(dir)
+
+	r := m.Lookup("129.146.1.1")
+	assert.True(t, r.Match)
+	assert.Equal(t, "oracle", r.Provider)
+	require.NotNil(t, r.Meta)
+	assert.Equal(t, "us-phoenix-1", r.Meta.Region)
+
+	r = m.Lookup("130.61.1.1")
+	assert.True(t, r.Match)
+	assert.Nil(t, r.Meta)
+
+	results := m.MatchAll([]string{"129.146.1.1"})
+	require.NotNil(t, results[0].Meta)
+	assert.Equal(t, []string{"OCI"}, results[0].Meta.Tags)
+}
+
+func TestMatcher_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, Save("google", &IPRange{IPv4: []string{"66.249.64.0/19", "34.0.0.0/15"}}, dir))
+	require.NoError(t, Save("googlecloud", &IPRange{IPv4: []string{"34.0.0.0/15"}}, dir))
+	require.NoError(t, Save("googlebot", &IPRange{IPv4: []string{"66.249.66.0/24"}}, dir))
+	require.NoError(t, Save("hetzner", &IPRange{IPv4: []string{"34.0.0.0/15"}}, dir))
+
+	providers := []Provider{{Name: "hetzner"}, {Name: "google"}, {Name: "googlecloud"}, {Name: "googlebot"}}
+	reversed := []Provider{providers[3], providers[2], providers[1], providers[0]}
+
+	t.Run("more specific prefix wins", func(t *testing.T) {
+		for _, ps := range [][]Provider{providers, reversed} {
+			m := NewMatcherFor(dir, ps)
+			assert.Equal(t, "googlebot", m.Match("66.249.66.1"))
+			assert.Equal(t, "google", m.Match("66.249.70.1"))
+		}
+	})
+
+	t.Run("priority breaks ties", func(t *testing.T) {
+		for _, ps := range [][]Provider{providers, reversed} {
+			assert.Equal(t, "googlecloud", NewMatcherFor(dir, ps).Match("34.0.1.1"))
+		}
+	})
+
+	t.Run("configured priority comes first", func(t *testing.T) {
+		withRegistry(t, providers, nil)
+		t.Cleanup(func() { Priority = DefaultPriority })
+
+		require.NoError(t, SetPriority([]string{"hetzner"}))
+		assert.Equal(t, "hetzner", NewMatcherFor(dir, providers).Match("34.0.1.1"))
+		assert.Equal(t, "googlebot", NewMatcherFor(dir, providers).Match("66.249.66.1"), "specificity still comes first")
+		assert.Equal(t, 0, PriorityRank("hetzner"))
+		assert.Less(t, PriorityRank("googlecloud"), PriorityRank("google"))
+
+		err := SetPriority([]string{"nonexistent"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown provider")
+	})
+
+	t.Run("unlisted providers rank by name", func(t *testing.T) {
+		require.NoError(t, Save("ovh", &IPRange{IPv4: []string{"34.0.0.0/15"}}, dir))
+		m := NewMatcherFor(dir, []Provider{{Name: "ovh"}, {Name: "hetzner"}})
+		assert.Equal(t, "hetzner", m.Match("34.0.1.1"))
+	})
+}
+
 func TestMatcher_MatchAll_Empty(t *testing.T) {
 	dir := t.TempDir()
 	m := NewMatcher(dir)
@@ -10,10 +10,19 @@ import (
 )
 
 func init() {
+	RegisterSource(Source{
+		Name:    "azure",
+		URL:     "", // Microsoft requires multi-step fetching
+		Collect: collectMicrosoft,
+	})
 	Register(Provider{
-		Name:   "microsoft",
-		URL:    "", // Microsoft requires multi-step fetching
-		Update: updateMicrosoft,
+		Name:        "microsoft",
+		Source:      "azure",
+		DisplayName: "Microsoft Azure",
+		Category:    CategoryCloud,
+		Color:       "blue",
+		Homepage:    "https://www.microsoft.com/en-us/download/details.aspx?id=56519",
+		License:     LicenseUnstated,
 	})
 }
 
@@ -45,10 +54,10 @@ type serviceTagValue struct {
 	} `json:"properties"`
 }
 
-// updateMicrosoft fetches IP ranges from all Azure clouds and merges them.
+// collectMicrosoft fetches IP ranges from all Azure clouds and merges them.
 // Required clouds (Public, USGov) must succeed; optional clouds (China, Germany)
 // are best-effort and log errors without failing the entire update.
-func updateMicrosoft(dataDir string) error {
+func collectMicrosoft() (map[string]*IPRange, error) {
 	ipRange := &IPRange{}
 	seen := make(map[string]bool)
 	successCount := 0
@@ -57,7 +66,7 @@ func updateMicrosoft(dataDir string) error {
 		downloadURL, err := discoverMicrosoftDownloadURL(cloud.ID)
 		if err != nil {
 			if cloud.Required {
-				return fmt.Errorf("discovering download URL for Azure %s (id=%s): %w", cloud.Cloud, cloud.ID, err)
+				return nil, fmt.Errorf("discovering download URL for Azure %s (id=%s): %w", cloud.Cloud, cloud.ID, err)
 			}
 			// Non-fatal: skip optional clouds that fail
 			continue
@@ -66,7 +75,7 @@ func updateMicrosoft(dataDir string) error {
 		ranges, err := fetchAndParseMicrosoftServiceTags(downloadURL)
 		if err != nil {
 			if cloud.Required {
-				return fmt.Errorf("fetching Azure %s service tags: %w", cloud.Cloud, err)
+				return nil, fmt.Errorf("fetching Azure %s service tags: %w", cloud.Cloud, err)
 			}
 			continue
 		}
@@ -88,10 +97,10 @@ func updateMicrosoft(dataDir string) error {
 	}
 
 	if successCount == 0 {
-		return fmt.Errorf("all Azure cloud fetches failed")
+		return nil, fmt.Errorf("all Azure cloud fetches failed")
 	}
 
-	return Save("microsoft", ipRange, dataDir)
+	return map[string]*IPRange{"microsoft": ipRange}, nil
 }
 
 // discoverMicrosoftDownloadURL scrapes the Microsoft download confirmation page
@@ -1,10 +1,47 @@
 package provider
 
+import "fmt"
+
+// openAIFeeds lists OpenAI's published crawler and agent IP lists. Each feed
+// becomes its own provider; "openai" is saved as their union.
+var openAIFeeds = []struct {
+	Provider    string
+	URL         string
+	Parse       ParseFunc
+	DisplayName string
+}{
+	{"openai-gptbot", "https://openai.com/gptbot-ranges.txt", parseOpenAI, "OpenAI GPTBot"},
+	{"openai-chatgpt-user", "https://openai.com/chatgpt-user.json", ParseJSONPrefixes, "OpenAI ChatGPT-User"},
+	{"openai-searchbot", "https://openai.com/searchbot.json", ParseJSONPrefixes, "OpenAI OAI-SearchBot"},
+}
+
+const openAIHomepage = "https://platform.openai.com/docs/bots"
+
 func init() {
+	RegisterSource(Source{
+		Name:    "openai",
+		Collect: collectOpenAI,
+	})
+	for _, feed := range openAIFeeds {
+		Register(Provider{
+			Name:        feed.Provider,
+			URL:         feed.URL,
+			Source:      "openai",
+			DisplayName: feed.DisplayName,
+			Category:    CategoryBot,
+			Color:       "cyan",
+			Homepage:    openAIHomepage,
+			License:     LicenseUnstated,
+		})
+	}
 	Register(Provider{
-		Name:  "openai",
-		URL:   "https://openai.com/gptbot-ranges.txt",
-		Parse: parseOpenAI,
+		Name:        "openai",
+		Source:      "openai",
+		DisplayName: "OpenAI",
+		Category:    CategoryAI,
+		Color:       "cyan",
+		Homepage:    openAIHomepage,
+		License:     LicenseUnstated,
 	})
 }
 
@@ -12,3 +49,27 @@ func init() {
 func parseOpenAI(data []byte) (*IPRange, error) {
 	return ParsePlainTextCIDRs(data)
 }
+
+// collectOpenAI fetches every OpenAI list and returns each one along with
+// their union. All lists are required, so a moved or renamed file fails the
+// update rather than silently dropping a bot.
+func collectOpenAI() (map[string]*IPRange, error) {
+	ranges := make(map[string]*IPRange, len(openAIFeeds)+1)
+	union := &IPRange{}
+
+	for _, feed := range openAIFeeds {
+		body, err := Fetch(feed.URL)
+		if err != nil {
+			return nil, fmt.Errorf("fetching %s: %w", feed.Provider, err)
+		}
+		ipRange, err := feed.Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", feed.Provider, err)
+		}
+		ranges[feed.Provider] = ipRange
+		appendUnique(union, ipRange)
+	}
+
+	ranges["openai"] = union
+	return ranges, nil
+}
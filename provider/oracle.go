@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	Register(Provider{
+		Name:        "oracle",
+		URL:         "https://docs.oracle.com/en-us/iaas/tools/public_ip_ranges.json",
+		Parse:       parseOracle,
+		DisplayName: "Oracle Cloud",
+		Category:    CategoryCloud,
+		Color:       "hi-red",
+		Homepage:    "https://docs.oracle.com/en-us/iaas/Content/General/Concepts/addressranges.htm",
+		License:     LicenseUnstated,
+	})
+}
+
+// parseOracle parses Oracle Cloud Infrastructure's public_ip_ranges.json,
+// which groups CIDRs by region and tags each one with the services it serves
+// (e.g. "OCI", "OSN", "OBJECT_STORAGE").
+func parseOracle(data []byte) (*IPRange, error) {
+	var result struct {
+		Regions []struct {
+			Region string `json:"region"`
+			CIDRs  []struct {
+				CIDR string   `json:"cidr"`
+				Tags []string `json:"tags"`
+			} `json:"cidrs"`
+		} `json:"regions"`
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("parsing Oracle data: %w", err)
+	}
+
+	ipRange := &IPRange{}
+	for _, region := range result.Regions {
+		for _, entry := range region.CIDRs {
+			cidr := strings.TrimSpace(entry.CIDR)
+			if cidr == "" {
+				continue
+			}
+			if _, dup := ipRange.Meta[cidr]; dup {
+				continue
+			}
+
+			if ClassifyCIDR(cidr) {
+				ipRange.IPv6 = append(ipRange.IPv6, cidr)
+			} else {
+				ipRange.IPv4 = append(ipRange.IPv4, cidr)
+			}
+			ipRange.setMeta(cidr, PrefixMeta{Region: region.Region, Tags: entry.Tags})
+		}
+	}
+
+	return ipRange, nil
+}
@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"math/big"
+	"net/netip"
+
+	"github.com/BenjiTrapp/ip-to-cloudprovider/cidr"
+)
+
+// Overlap is the address space two providers both claim.
+type Overlap struct {
+	A             string   `json:"a"`
+	B             string   `json:"b"`
+	IPv4          []string `json:"ipv4,omitempty"`
+	IPv6          []string `json:"ipv6,omitempty"`
+	IPv4Addresses *big.Int `json:"ipv4_addresses"`
+	IPv6Addresses *big.Int `json:"ipv6_addresses"`
+}
+
+// LoadPrefixes loads a provider's data as an aggregated prefix list.
+func LoadPrefixes(providerName, dataDir string) ([]netip.Prefix, error) {
+	ipRange, err := Load(providerName, dataDir)
+	if err != nil {
+		return nil, err
+	}
+	return cidr.Aggregate(ipRange.Prefixes()), nil
+}
+
+// Overlaps computes the shared address space of every pair of the given
+// providers from their stored data. Pairs are returned in the order of
+// providers, and pairs that share nothing are left out, as are providers
+// without data.
+func Overlaps(dataDir string, providers []Provider) []Overlap {
+	type loaded struct {
+		name     string
+		prefixes []netip.Prefix
+	}
+	var sets []loaded
+	for _, p := range providers {
+		prefixes, err := LoadPrefixes(p.Name, dataDir)
+		if err != nil || len(prefixes) == 0 {
+			continue
+		}
+		sets = append(sets, loaded{p.Name, prefixes})
+	}
+
+	var out []Overlap
+	for i := range sets {
+		for j := i + 1; j < len(sets); j++ {
+			shared := cidr.Intersect(sets[i].prefixes, sets[j].prefixes)
+			if len(shared) == 0 {
+				continue
+			}
+			shared4, shared6 := splitFamilies(shared)
+			v4, v6 := cidr.Count(shared)
+			out = append(out, Overlap{
+				A:             sets[i].name,
+				B:             sets[j].name,
+				IPv4:          cidr.Strings(shared4),
+				IPv6:          cidr.Strings(shared6),
+				IPv4Addresses: v4,
+				IPv6Addresses: v6,
+			})
+		}
+	}
+	return out
+}
+
+// splitFamilies separates IPv4 from IPv6 prefixes, keeping their order.
+func splitFamilies(prefixes []netip.Prefix) (ipv4, ipv6 []netip.Prefix) {
+	for _, p := range prefixes {
+		if p.Addr().Is4() {
+			ipv4 = append(ipv4, p)
+		} else {
+			ipv6 = append(ipv6, p)
+		}
+	}
+	return ipv4, ipv6
+}
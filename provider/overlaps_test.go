@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlaps(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, Save("microsoft", &IPRange{
+		IPv4: []string{"4.148.0.0/14", "20.0.0.0/11"},
+		IPv6: []string{"2603:1000::/24"},
+	}, dir))
+	require.NoError(t, Save("githubactions", &IPRange{
+		IPv4: []string{"4.148.0.0/16", "4.150.0.0/24", "140.82.112.0/20"},
+		IPv6: []string{"2603:1030:800::/48"},
+	}, dir))
+	require.NoError(t, Save("cloudflare", &IPRange{IPv4: []string{"104.16.0.0/13"}}, dir))
+
+	providers := []Provider{{Name: "microsoft"}, {Name: "githubactions"}, {Name: "cloudflare"}, {Name: "nodata"}}
+	overlaps := Overlaps(dir, providers)
+	require.Len(t, overlaps, 1, "disjoint pairs and providers without data are left out")
+
+	o := overlaps[0]
+	assert.Equal(t, "microsoft", o.A)
+	assert.Equal(t, "githubactions", o.B)
+	assert.Equal(t, []string{"4.148.0.0/16", "4.150.0.0/24"}, o.IPv4)
+	assert.Equal(t, []string{"2603:1030:800::/48"}, o.IPv6)
+	assert.Equal(t, int64(65536+256), o.IPv4Addresses.Int64())
+	assert.Equal(t, uint(80+1), uint(o.IPv6Addresses.BitLen()))
+
+	assert.Empty(t, Overlaps(dir, []Provider{{Name: "cloudflare"}}))
+}
+
+func TestLoadPrefixes(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, Save("amazon", &IPRange{IPv4: []string{"13.224.0.0/15", "13.226.0.0/15"}}, dir))
+
+	prefixes, err := LoadPrefixes("amazon", dir)
+	require.NoError(t, err)
+	require.Len(t, prefixes, 1)
+	assert.Equal(t, "13.224.0.0/14", prefixes[0].String())
+
+	_, err = LoadPrefixes("nonexistent", t.TempDir())
+	assert.Error(t, err)
+}
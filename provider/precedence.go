@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Several providers can contain the same address: CloudFront edges are part of
+// the AMAZON ranges, Googlebot's are part of Google's, a Tor exit sits in a
+// hoster's ASN. A lookup therefore picks the local inventory if it contains
+// the address, else the most specific (longest) matching prefix, and among
+// equally specific prefixes the provider ranked first in Priority. Providers
+// missing from Priority rank after the listed ones, in name order, so the
+// result never depends on registration order.
+
+// DefaultPriority ranks providers whose published ranges overlap: your own
+// inventory first, then the narrower product over the broader platform.
+var DefaultPriority = []string{
+	InventoryProviderName,
+	"googlebot", "googlecloud", "google",
+	"cloudfront", "amazon",
+	"openai-gptbot", "openai-chatgpt-user", "openai-searchbot", "openai",
+	"anthropic-inbound", "anthropic-outbound", "anthropic",
+	"githubactions", "githubhooks", "githubpages", "github",
+	"apple-private-relay", "cloudflare-warp", "cloudflare",
+	"m365", "microsoft",
+}
+
+// Priority is the precedence list in effect; see SetPriority.
+var Priority = DefaultPriority
+
+// SetPriority puts the given providers, in order, ahead of the remaining
+// entries of DefaultPriority. Every name must be registered, since a typo
+// would otherwise silently change nothing.
+func SetPriority(names []string) error {
+	for _, name := range names {
+		if ByName(name) == nil {
+			return fmt.Errorf("unknown provider %q in priority list", name)
+		}
+	}
+
+	priority := slices.Clone(names)
+	for _, name := range DefaultPriority {
+		if !slices.Contains(priority, name) {
+			priority = append(priority, name)
+		}
+	}
+	Priority = priority
+	return nil
+}
+
+// PriorityRank returns the position of a provider in Priority, or
+// len(Priority) for unlisted providers.
+func PriorityRank(name string) int {
+	if i := slices.Index(Priority, name); i >= 0 {
+		return i
+	}
+	return len(Priority)
+}
+
+// SortByPriority orders providers by their rank in Priority, then by name.
+func SortByPriority(providers []Provider) []Provider {
+	sorted := slices.Clone(providers)
+	slices.SortStableFunc(sorted, func(a, b Provider) int {
+		if ra, rb := PriorityRank(a.Name), PriorityRank(b.Name); ra != rb {
+			return ra - rb
+		}
+		switch {
+		case a.Name < b.Name:
+			return -1
+		case a.Name > b.Name:
+			return 1
+		}
+		return 0
+	})
+	return sorted
+}
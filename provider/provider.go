@@ -9,10 +9,14 @@ import (
 	"io/fs"
 	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/BenjiTrapp/ip-to-cloudprovider/cidr"
 )
 
 // EmbeddedData holds a build-time snapshot of provider IP ranges, laid out as
@@ -24,8 +28,8 @@ var EmbeddedData fs.FS
 // embeddedRange reads a provider's IP ranges from the embedded snapshot.
 // Returns nil if no embedded data is available for the provider.
 func embeddedRange(providerName string) (*IPRange, error) {
-	if EmbeddedData == nil {
-		return nil, fmt.Errorf("no embedded data")
+	if !hasEmbedded(providerName) {
+		return nil, fmt.Errorf("no embedded data for %s", providerName)
 	}
 	data, err := fs.ReadFile(EmbeddedData, providerName+"/ipranges.json")
 	if err != nil {
@@ -39,10 +43,15 @@ func embeddedRange(providerName string) (*IPRange, error) {
 }
 
 // hasEmbedded reports whether the embedded snapshot contains data for a provider.
+// Providers with a RefreshInterval never use it: a build-time snapshot of a
+// short-lived list is out of date on arrival.
 func hasEmbedded(providerName string) bool {
 	if EmbeddedData == nil {
 		return false
 	}
+	if p := ByName(providerName); p != nil && p.RefreshInterval > 0 {
+		return false
+	}
 	_, err := fs.Stat(EmbeddedData, providerName+"/ipranges.json")
 	return err == nil
 }
@@ -64,6 +73,43 @@ var httpClient = &http.Client{
 type IPRange struct {
 	IPv4 []string `json:"ipv4"`
 	IPv6 []string `json:"ipv6"`
+
+	// Meta holds optional per-prefix details, keyed by CIDR as it appears in
+	// IPv4/IPv6. Providers that publish no metadata leave it nil.
+	Meta map[string]PrefixMeta `json:"meta,omitempty"`
+}
+
+// PrefixMeta describes a single published prefix (e.g. the cloud region it
+// serves). Empty fields are omitted from the stored JSON.
+type PrefixMeta struct {
+	Region  string   `json:"region,omitempty"`
+	Country string   `json:"country,omitempty"`
+	City    string   `json:"city,omitempty"`
+	ASN     int      `json:"asn,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+
+	// Local inventory details (see RegisterInventory).
+	Name        string            `json:"name,omitempty"`
+	Owner       string            `json:"owner,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// MetaFor returns the metadata recorded for a CIDR, or nil if there is none.
+func (r *IPRange) MetaFor(cidr string) *PrefixMeta {
+	meta, ok := r.Meta[cidr]
+	if !ok {
+		return nil
+	}
+	return &meta
+}
+
+// setMeta records metadata for a CIDR, allocating the map on first use.
+func (r *IPRange) setMeta(cidr string, meta PrefixMeta) {
+	if r.Meta == nil {
+		r.Meta = make(map[string]PrefixMeta)
+	}
+	r.Meta[cidr] = meta
 }
 
 // ParseFunc parses raw response bytes into an IPRange.
@@ -73,12 +119,42 @@ type ParseFunc func(data []byte) (*IPRange, error)
 // multi-step fetching (e.g. Microsoft). It writes data directly.
 type UpdateFunc func(dataDir string) error
 
+// LoadFunc reads a provider's ranges from somewhere other than the data
+// directory, such as a local inventory file.
+type LoadFunc func() (*IPRange, error)
+
 // Provider represents a cloud provider with its metadata and parsing logic.
 type Provider struct {
 	Name   string
 	URL    string
 	Parse  ParseFunc
 	Update UpdateFunc // if set, used instead of URL+Parse
+	Source string     // if set, updated through the named shared Source
+	Load   LoadFunc   // if set, data comes from here; never updated or embedded
+	Expr   string     // if set, derived from other providers (see Derive)
+
+	// Descriptive details shown by list, scan and JSON output.
+	DisplayName string // human-readable name, e.g. "Amazon AWS"; defaults to Name
+	Category    string // one of the Category* constants, or free-form for user providers
+	Color       string // display color, e.g. "red", "hi-magenta" or "black on white"
+	Homepage    string // documentation page for the published ranges
+	License     string // data license as an SPDX id, or LicenseUnstated
+
+	// RefreshInterval is how long fetched data stays current. Zero means the
+	// ranges change rarely and the daily update is enough.
+	RefreshInterval time.Duration
+}
+
+// LicenseUnstated marks ranges whose publisher states no license. Most lists
+// are published for allowlisting without any terms attached.
+const LicenseUnstated = "unstated"
+
+// Title returns the provider's display name, falling back to its name.
+func (p *Provider) Title() string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+	return p.Name
 }
 
 // Registry holds all registered providers in order.
@@ -157,27 +233,55 @@ func FetchAndParse(p *Provider) (*IPRange, error) {
 }
 
 // UpdateProvider fetches and saves the IP ranges for a provider.
-// If the provider has a custom Update function, it is used instead.
+// If the provider belongs to a shared Source, the whole source is refreshed
+// (which also updates its sibling providers). If it has a custom Update
+// function, that is used instead of URL+Parse. A derived provider is
+// recomputed from its operands' current data, which is not refreshed.
+// Saved derived providers that use the updated data are recomputed after it.
 func UpdateProvider(p *Provider, dataDir string) error {
+	saved, err := updateProvider(p, dataDir)
+	if err != nil {
+		return err
+	}
+	return updateDependents(saved, dataDir)
+}
+
+// updateProvider is UpdateProvider without refreshing dependents. It returns
+// the names of the providers it saved.
+func updateProvider(p *Provider, dataDir string) ([]string, error) {
+	if p.Load != nil {
+		return nil, fmt.Errorf("provider %s is local and is never updated", p.Name)
+	}
+	if p.Expr != "" {
+		ipRange, err := Derive(p, dataDir)
+		if err != nil {
+			return nil, err
+		}
+		return []string{p.Name}, Save(p.Name, ipRange, dataDir)
+	}
+	if p.Source != "" {
+		s := SourceByName(p.Source)
+		if s == nil {
+			return nil, fmt.Errorf("provider %s references unknown source %s", p.Name, p.Source)
+		}
+		return UpdateSource(s, dataDir)
+	}
 	if p.Update != nil {
-		return p.Update(dataDir)
+		return []string{p.Name}, p.Update(dataDir)
 	}
 
 	ipRange, err := FetchAndParse(p)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return Save(p.Name, ipRange, dataDir)
+	return []string{p.Name}, Save(p.Name, ipRange, dataDir)
 }
 
-// Save writes an IPRange to disk as JSON, validating CIDRs before saving.
+// Save writes an IPRange to disk as JSON, normalising it first (see
+// Normalize).
 func Save(providerName string, ipRange *IPRange, dataDir string) error {
-	// Validate and filter CIDRs
-	validated := &IPRange{
-		IPv4: validateCIDRs(ipRange.IPv4),
-		IPv6: validateCIDRs(ipRange.IPv6),
-	}
+	validated := Normalize(ipRange)
 
 	dir := filepath.Join(dataDir, providerName)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -198,12 +302,22 @@ func Save(providerName string, ipRange *IPRange, dataDir string) error {
 }
 
 // Load reads an IPRange from disk, falling back to the embedded snapshot when
-// no data file exists in the data directory.
+// no data file exists in the data directory. Local providers are read through
+// their own LoadFunc instead, and derived providers without a data file are
+// computed from their operands.
 func Load(providerName, dataDir string) (*IPRange, error) {
+	p := ByName(providerName)
+	if p != nil && p.Load != nil {
+		return p.Load()
+	}
+
 	path := filepath.Join(dataDir, providerName, "ipranges.json")
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
+			if p != nil && p.Expr != "" {
+				return Derive(p, dataDir)
+			}
 			if ipRange, embErr := embeddedRange(providerName); embErr == nil {
 				return ipRange, nil
 			}
@@ -220,15 +334,46 @@ func Load(providerName, dataDir string) (*IPRange, error) {
 }
 
 // HasData returns true if the given provider has data available, either as a
-// file in the data directory or in the embedded snapshot.
+// file in the data directory or in the embedded snapshot. A derived provider
+// has data when all of its operands do.
 func HasData(providerName, dataDir string) bool {
+	p := ByName(providerName)
+	if p != nil && p.Load != nil {
+		return true
+	}
 	path := filepath.Join(dataDir, providerName, "ipranges.json")
 	if _, err := os.Stat(path); err == nil {
 		return true
 	}
+	if p != nil && p.Expr != "" {
+		e, err := parseSetExpr(p.Expr)
+		if err != nil {
+			return false
+		}
+		for _, name := range e.operands() {
+			if !HasData(name, dataDir) {
+				return false
+			}
+		}
+		return true
+	}
 	return hasEmbedded(providerName)
 }
 
+// IsStale reports whether a provider with a RefreshInterval lacks data in the
+// data directory younger than that interval. Such providers never fall back to
+// the embedded snapshot. Providers without an interval are never stale.
+func IsStale(p *Provider, dataDir string) bool {
+	if p.RefreshInterval <= 0 || p.Load != nil {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(dataDir, p.Name, "ipranges.json"))
+	if err != nil {
+		return true
+	}
+	return time.Since(info.ModTime()) > p.RefreshInterval
+}
+
 // HasAnyData returns true if at least one provider has data loaded.
 func HasAnyData(dataDir string) bool {
 	for _, p := range Registry {
@@ -240,19 +385,122 @@ func HasAnyData(dataDir string) bool {
 }
 
 // CheckIP checks if an IP is in the provider's ranges. Returns the provider
-// name if found, or an empty string if not.
+// name if found, or an empty string if not. Overlaps are resolved as in
+// Matcher.Lookup.
 // NOTE: For batch operations, use Matcher instead (pre-loads and caches data).
 func CheckIP(ip, dataDir string) string {
-	for _, p := range Registry {
-		ipRange, err := Load(p.Name, dataDir)
-		if err != nil {
+	return NewMatcher(dataDir).Match(ip)
+}
+
+// normalizeCIDR returns the canonical form of a prefix, turning a bare
+// IP address into a /32 or /128.
+func normalizeCIDR(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if ip := net.ParseIP(s); ip != nil {
+		if ip.To4() != nil {
+			return ip.String() + "/32", nil
+		}
+		return ip.String() + "/128", nil
+	}
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid cidr %q", s)
+	}
+	return ipNet.String(), nil
+}
+
+// Normalize returns the canonical form of an IPRange: invalid CIDRs are
+// dropped, host bits cleared, and overlapping or adjacent prefixes that carry
+// the same metadata (or none) are aggregated. Prefixes are sorted by address.
+// When a prefix is listed with different metadata, one entry is kept
+// deterministically, preferring one that has metadata.
+func Normalize(ipRange *IPRange) *IPRange {
+	groups := make(map[string][]netip.Prefix)
+	metas := make(map[string]PrefixMeta)
+	for _, cidrs := range [][]string{ipRange.IPv4, ipRange.IPv6} {
+		for _, s := range cidrs {
+			prefix, err := netip.ParsePrefix(strings.TrimSpace(s))
+			if err != nil {
+				continue
+			}
+			var key string
+			if meta, ok := ipRange.Meta[s]; ok {
+				data, _ := json.Marshal(meta)
+				key = string(data)
+				metas[key] = meta
+			}
+			groups[key] = append(groups[key], prefix)
+		}
+	}
+
+	type entry struct {
+		prefix netip.Prefix
+		key    string
+	}
+	var entries []entry
+	for key, prefixes := range groups {
+		for _, prefix := range cidr.Aggregate(prefixes) {
+			entries = append(entries, entry{prefix, key})
+		}
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		if c := a.prefix.Addr().Compare(b.prefix.Addr()); c != 0 {
+			return c
+		}
+		if c := a.prefix.Bits() - b.prefix.Bits(); c != 0 {
+			return c
+		}
+		if (a.key == "") != (b.key == "") {
+			if a.key == "" {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a.key, b.key)
+	})
+
+	result := &IPRange{}
+	for i, e := range entries {
+		if i > 0 && entries[i-1].prefix == e.prefix {
 			continue
 		}
-		if IsIPInRange(ip, ipRange.IPv4) || IsIPInRange(ip, ipRange.IPv6) {
-			return p.Name
+		s := e.prefix.String()
+		if e.prefix.Addr().Is4() {
+			result.IPv4 = append(result.IPv4, s)
+		} else {
+			result.IPv6 = append(result.IPv6, s)
+		}
+		if e.key != "" {
+			result.setMeta(s, metas[e.key])
+		}
+	}
+	return result
+}
+
+// Prefixes returns the valid IPv4 and IPv6 prefixes of the range.
+func (r *IPRange) Prefixes() []netip.Prefix {
+	var out []netip.Prefix
+	for _, cidrs := range [][]string{r.IPv4, r.IPv6} {
+		for _, s := range cidrs {
+			if prefix, err := netip.ParsePrefix(strings.TrimSpace(s)); err == nil {
+				out = append(out, prefix.Masked())
+			}
+		}
+	}
+	return out
+}
+
+// rangeFromPrefixes splits prefixes into an IPRange by family.
+func rangeFromPrefixes(prefixes []netip.Prefix) *IPRange {
+	result := &IPRange{}
+	for _, prefix := range prefixes {
+		if prefix.Addr().Is4() {
+			result.IPv4 = append(result.IPv4, prefix.String())
+		} else {
+			result.IPv6 = append(result.IPv6, prefix.String())
 		}
 	}
-	return ""
+	return result
 }
 
 // IsIPInRange checks if an IP belongs to any of the given CIDR ranges.
@@ -299,22 +547,77 @@ func ParsePlainTextCIDRs(data []byte) (*IPRange, error) {
 	return ipRange, nil
 }
 
-// validateCIDRs filters a list of CIDRs, keeping only valid ones.
-func validateCIDRs(cidrs []string) []string {
-	if cidrs == nil {
-		return nil
+// ParseIPList parses a list with one IP address or CIDR per line, as published
+// for Tor exits and egress proxies. Bare addresses become host prefixes, and
+// blank lines, "#" comments and invalid entries are skipped.
+func ParseIPList(data []byte) (*IPRange, error) {
+	ipRange := &IPRange{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cidr, err := normalizeCIDR(line)
+		if err != nil {
+			continue
+		}
+		if strings.Contains(cidr, ":") {
+			ipRange.IPv6 = append(ipRange.IPv6, cidr)
+		} else {
+			ipRange.IPv4 = append(ipRange.IPv4, cidr)
+		}
+	}
+	return ipRange, nil
+}
+
+// ParseJSONPrefixes parses the JSON prefix list format published by Google,
+// OpenAI, and most crawler operators:
+//
+//	{"prefixes": [{"ipv4Prefix": "..."}, {"ipv6Prefix": "..."}]}
+func ParseJSONPrefixes(data []byte) (*IPRange, error) {
+	var result struct {
+		Prefixes []struct {
+			IPv4Prefix string `json:"ipv4Prefix"`
+			IPv6Prefix string `json:"ipv6Prefix"`
+		} `json:"prefixes"`
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("parsing JSON prefix list: %w", err)
 	}
-	valid := make([]string, 0, len(cidrs))
-	for _, cidr := range cidrs {
-		cidr = strings.TrimSpace(cidr)
-		if _, _, err := net.ParseCIDR(cidr); err == nil {
-			valid = append(valid, cidr)
+
+	ipRange := &IPRange{}
+	for _, prefix := range result.Prefixes {
+		if prefix.IPv4Prefix != "" {
+			ipRange.IPv4 = append(ipRange.IPv4, prefix.IPv4Prefix)
+		}
+		if prefix.IPv6Prefix != "" {
+			ipRange.IPv6 = append(ipRange.IPv6, prefix.IPv6Prefix)
 		}
 	}
-	if len(valid) == 0 {
-		return nil
+
+	return ipRange, nil
+}
+
+// ParseCommentedText parses a plain text list of CIDRs that may contain
+// comment lines starting with "#", such as the ipverse ASN lists.
+func ParseCommentedText(data []byte) (*IPRange, error) {
+	return splitIPv4v6(parseCommentedCIDRs(string(data))), nil
+}
+
+// parseCommentedCIDRs parses lines of CIDRs, skipping comment lines (# prefix)
+// and empty lines.
+func parseCommentedCIDRs(text string) []string {
+	lines := strings.Split(text, "\n")
+	var cidrs []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cidrs = append(cidrs, line)
 	}
-	return valid
+	return cidrs
 }
 
 // DefaultDataDir returns the default data directory based on OS conventions.
@@ -6,7 +6,10 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -24,6 +27,15 @@ func TestRegistry(t *testing.T) {
 			"google", "googlecloud", "googlebot",
 			"openai", "digitalocean", "microsoft",
 			"alibaba", "anthropic", "hetzner",
+			"anthropic-inbound", "anthropic-outbound",
+			"openai-gptbot", "openai-chatgpt-user", "openai-searchbot",
+			"oracle", "linode", "vultr",
+			"ovh", "scaleway", "contabo",
+			"bingbot", "applebot", "duckduckbot",
+			"perplexitybot", "perplexity-user", "commoncrawl",
+			"cloudfront", "fastly", "akamai", "bunnycdn",
+			"tor", "apple-private-relay", "cloudflare-warp", "zscaler",
+			"m365",
 		}
 		names := Names()
 		for _, name := range expected {
@@ -31,6 +43,52 @@ func TestRegistry(t *testing.T) {
 		}
 	})
 
+	t.Run("crawlers share the bot category", func(t *testing.T) {
+		for _, name := range []string{
+			"googlebot", "bingbot", "applebot", "duckduckbot", "perplexitybot",
+			"perplexity-user", "commoncrawl", "openai-gptbot", "openai-searchbot",
+		} {
+			p := ByName(name)
+			require.NotNil(t, p, name)
+			assert.Equal(t, CategoryBot, p.Category, name)
+		}
+	})
+
+	t.Run("CDNs share the cdn category", func(t *testing.T) {
+		for _, name := range []string{"cloudflare", "cloudfront", "fastly", "akamai", "bunnycdn"} {
+			p := ByName(name)
+			require.NotNil(t, p, name)
+			assert.Equal(t, CategoryCDN, p.Category, name)
+		}
+	})
+
+	t.Run("built-ins carry descriptors", func(t *testing.T) {
+		for _, p := range Registry {
+			assert.NotEmpty(t, p.DisplayName, p.Name)
+			assert.NotEmpty(t, p.Category, p.Name)
+			assert.NotEmpty(t, p.Homepage, p.Name)
+			assert.NotEmpty(t, p.License, p.Name)
+		}
+	})
+
+	t.Run("ByCategory filters in registry order", func(t *testing.T) {
+		bots := ByCategory(CategoryBot)
+		require.NotEmpty(t, bots)
+		for _, p := range bots {
+			assert.Equal(t, CategoryBot, p.Category, p.Name)
+		}
+		assert.Len(t, ByCategory(), len(Registry))
+		assert.Empty(t, ByCategory("nonexistent"))
+		assert.Len(t, ByCategory(CategoryBot, CategoryCDN), len(bots)+len(ByCategory(CategoryCDN)))
+	})
+
+	t.Run("Categories lists each category once", func(t *testing.T) {
+		categories := Categories()
+		assert.Contains(t, categories, CategoryCloud)
+		assert.Contains(t, categories, CategoryBot)
+		assert.IsIncreasing(t, categories)
+	})
+
 	t.Run("ByName returns correct provider", func(t *testing.T) {
 		p := ByName("amazon")
 		require.NotNil(t, p)
@@ -103,6 +161,173 @@ func TestParseAmazon(t *testing.T) {
 	}
 }
 
+func TestSplitAmazon(t *testing.T) {
+	input := `{
+		"prefixes": [
+			{"ip_prefix": "13.224.0.0/14", "region": "GLOBAL", "service": "AMAZON"},
+			{"ip_prefix": "13.224.0.0/14", "region": "GLOBAL", "service": "CLOUDFRONT"},
+			{"ip_prefix": "52.94.76.0/22", "region": "us-west-2", "service": "AMAZON"},
+			{"ip_prefix": "52.94.76.0/22", "region": "us-west-2", "service": "EC2"}
+		],
+		"ipv6_prefixes": [
+			{"ipv6_prefix": "2600:9000::/28", "region": "GLOBAL", "service": "CLOUDFRONT"}
+		]
+	}`
+
+	ranges, err := splitAmazon([]byte(input))
+	require.NoError(t, err)
+
+	amazon := ranges["amazon"]
+	assert.Equal(t, []string{"13.224.0.0/14", "52.94.76.0/22"}, amazon.IPv4)
+	assert.Equal(t, []string{"2600:9000::/28"}, amazon.IPv6)
+	assert.Equal(t, &PrefixMeta{Region: "us-west-2", Tags: []string{"EC2"}}, amazon.MetaFor("52.94.76.0/22"))
+	assert.Equal(t, []string{"CLOUDFRONT"}, amazon.MetaFor("13.224.0.0/14").Tags)
+
+	cloudfront := ranges["cloudfront"]
+	assert.Equal(t, []string{"13.224.0.0/14"}, cloudfront.IPv4)
+	assert.Equal(t, []string{"2600:9000::/28"}, cloudfront.IPv6)
+
+	_, err = splitAmazon([]byte("{broken"))
+	assert.Error(t, err)
+}
+
+func TestParseFastly(t *testing.T) {
+	result, err := parseFastly([]byte(`{"addresses": ["151.101.0.0/16"], "ipv6_addresses": ["2a04:4e40::/32"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"151.101.0.0/16"}, result.IPv4)
+	assert.Equal(t, []string{"2a04:4e40::/32"}, result.IPv6)
+
+	_, err = parseFastly([]byte("{broken"))
+	assert.Error(t, err)
+}
+
+func TestParseBunnyEdgeServers(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantV4  []string
+		wantV6  []string
+		wantErr bool
+	}{
+		{
+			name:   "json array of bare IPs",
+			input:  `["89.187.188.227", "185.152.64.17", "2400:52e0:1a00::1", "bogus"]`,
+			wantV4: []string{"89.187.188.227/32", "185.152.64.17/32"},
+			wantV6: []string{"2400:52e0:1a00::1/128"},
+		},
+		{
+			name:   "xml form",
+			input:  `<ArrayOfstring xmlns="http://schemas.microsoft.com/2003/10/Serialization/Arrays"><string>89.187.188.227</string><string> 185.152.64.17 </string></ArrayOfstring>`,
+			wantV4: []string{"89.187.188.227/32", "185.152.64.17/32"},
+		},
+		{
+			name:    "neither json nor xml",
+			input:   "<html>maintenance</html>",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := parseBunnyEdgeServers([]byte(tc.input))
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantV4, result.IPv4)
+			assert.Equal(t, tc.wantV6, result.IPv6)
+		})
+	}
+}
+
+func TestParseIPList(t *testing.T) {
+	input := "# Tor bulk exit list\n185.220.101.1\n\n 2a0b:f4c2::1 \n185.220.100.0/22\nnot-an-ip\n"
+	result, err := ParseIPList([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"185.220.101.1/32", "185.220.100.0/22"}, result.IPv4)
+	assert.Equal(t, []string{"2a0b:f4c2::1/128"}, result.IPv6)
+}
+
+func TestParseZscalerCENR(t *testing.T) {
+	input := `{
+		"zscaler.net": {
+			"continent : EMEA": {
+				"city : Amsterdam II": [
+					{"range": "165.225.240.0/23", "vpn": "ams2-vpn.zscaler.net"},
+					{"range": "2a03:eec0:1411::/48"}
+				],
+				"city : Frankfurt IV": [{"range": "165.225.72.0/22"}]
+			},
+			"continent : Americas": {
+				"city : Chicago": [{"range": "165.225.0.0/23"}, {"range": "165.225.240.0/23"}]
+			}
+		}
+	}`
+
+	result, err := parseZscalerCENR([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"165.225.0.0/23", "165.225.240.0/23", "165.225.72.0/22"}, result.IPv4)
+	assert.Equal(t, []string{"2a03:eec0:1411::/48"}, result.IPv6)
+	assert.Equal(t, &PrefixMeta{Region: "EMEA", City: "Frankfurt IV"}, result.MetaFor("165.225.72.0/22"))
+	assert.Equal(t, "Americas", result.MetaFor("165.225.240.0/23").Region, "first listing wins")
+
+	_, err = parseZscalerCENR([]byte("[]"))
+	assert.Error(t, err)
+}
+
+func TestFetchAndMerge_KeepsMeta(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"zscaler.net": {"continent : EMEA": {"city : %s": [{"range": "165.225.%s.0/23"}]}}}`,
+			strings.TrimPrefix(r.URL.Path, "/"), map[string]string{"/Paris": "1", "/Milan": "2"}[r.URL.Path])
+	}))
+	defer server.Close()
+
+	merged, err := fetchAndMerge([]string{server.URL + "/Paris", server.URL + "/Milan"}, parseZscalerCENR)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"165.225.1.0/23", "165.225.2.0/23"}, merged.IPv4)
+	assert.Equal(t, "Milan", merged.MetaFor("165.225.2.0/23").City)
+}
+
+func TestLoad_EmbeddedFallback(t *testing.T) {
+	orig := EmbeddedData
+	defer func() { EmbeddedData = orig }()
+	EmbeddedData = fstest.MapFS{
+		"cloudflare/ipranges.json": {Data: []byte(`{"ipv4":["104.16.0.0/13"]}`)},
+		"tor/ipranges.json":        {Data: []byte(`{"ipv4":["185.220.101.1/32"]}`)},
+	}
+	dir := t.TempDir()
+
+	ipRange, err := Load("cloudflare", dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"104.16.0.0/13"}, ipRange.IPv4)
+	assert.True(t, HasData("cloudflare", dir))
+
+	_, err = Load("tor", dir)
+	assert.Error(t, err, "short-lived lists are never served from the snapshot")
+	assert.False(t, HasData("tor", dir))
+}
+
+func TestIsStale(t *testing.T) {
+	dir := t.TempDir()
+	fresh := &Provider{Name: "fresh", RefreshInterval: time.Hour}
+	old := &Provider{Name: "old", RefreshInterval: time.Hour}
+	missing := &Provider{Name: "missing", RefreshInterval: time.Hour}
+	daily := &Provider{Name: "daily"}
+
+	for _, name := range []string{"fresh", "old", "daily"} {
+		require.NoError(t, Save(name, &IPRange{IPv4: []string{"1.2.3.0/24"}}, dir))
+	}
+	past := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "old", "ipranges.json"), past, past))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "daily", "ipranges.json"), past, past))
+
+	assert.False(t, IsStale(fresh, dir))
+	assert.True(t, IsStale(old, dir))
+	assert.True(t, IsStale(missing, dir), "no fresh download counts as stale")
+	assert.False(t, IsStale(daily, dir), "providers without an interval never go stale")
+}
+
 func TestParseCloudflare(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -330,6 +555,48 @@ func TestParseOpenAI(t *testing.T) {
 	}
 }
 
+func TestCollectOpenAI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gptbot-ranges.txt":
+			fmt.Fprint(w, "20.171.206.0/24\n52.230.152.0/24\n")
+		case "/chatgpt-user.json":
+			fmt.Fprint(w, `{"creationTime": "2025-01-01T00:00:00", "prefixes": [{"ipv4Prefix": "23.98.179.16/28"}, {"ipv6Prefix": "2603:1030:7::/48"}]}`)
+		case "/searchbot.json":
+			fmt.Fprint(w, `{"prefixes": [{"ipv4Prefix": "20.42.10.176/28"}, {"ipv4Prefix": "52.230.152.0/24"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	orig := append(openAIFeeds[:0:0], openAIFeeds...)
+	defer func() { openAIFeeds = orig }()
+	for i := range openAIFeeds {
+		openAIFeeds[i].URL = server.URL + orig[i].URL[len("https://openai.com"):]
+	}
+
+	t.Run("splits lists and builds union", func(t *testing.T) {
+		ranges, err := collectOpenAI()
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"20.171.206.0/24", "52.230.152.0/24"}, ranges["openai-gptbot"].IPv4)
+		assert.Equal(t, []string{"23.98.179.16/28"}, ranges["openai-chatgpt-user"].IPv4)
+		assert.Equal(t, []string{"2603:1030:7::/48"}, ranges["openai-chatgpt-user"].IPv6)
+		assert.Equal(t, []string{"20.42.10.176/28", "52.230.152.0/24"}, ranges["openai-searchbot"].IPv4)
+
+		assert.Equal(t, []string{"20.171.206.0/24", "52.230.152.0/24", "23.98.179.16/28", "20.42.10.176/28"}, ranges["openai"].IPv4)
+		assert.Equal(t, []string{"2603:1030:7::/48"}, ranges["openai"].IPv6)
+	})
+
+	t.Run("missing list fails the update", func(t *testing.T) {
+		openAIFeeds[2].URL = server.URL + "/gone.json"
+		_, err := collectOpenAI()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "openai-searchbot")
+	})
+}
+
 func TestParseDigitalOcean(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -376,7 +643,53 @@ func TestParseDigitalOcean(t *testing.T) {
 	}
 }
 
-func TestParseAlibaba(t *testing.T) {
+func TestParseGeofeed(t *testing.T) {
+	t.Run("keeps location per prefix", func(t *testing.T) {
+		input := `# Linode geofeed
+# prefix,country,region,city,postal
+
+139.162.0.0/21,NL,NL-NH,Amsterdam,
+2600:3c00::/48,US,US-TX,Richardson,75080
+172.105.0.0/19,DE,,"Frankfurt am Main"
+45.79.0.0/21,US
+`
+		result, err := ParseGeofeed([]byte(input))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"139.162.0.0/21", "172.105.0.0/19", "45.79.0.0/21"}, result.IPv4)
+		assert.Equal(t, []string{"2600:3c00::/48"}, result.IPv6)
+
+		assert.Equal(t, &PrefixMeta{Country: "NL", Region: "NL-NH", City: "Amsterdam"}, result.MetaFor("139.162.0.0/21"))
+		assert.Equal(t, &PrefixMeta{Country: "DE", City: "Frankfurt am Main"}, result.MetaFor("172.105.0.0/19"))
+		assert.Equal(t, &PrefixMeta{Country: "US"}, result.MetaFor("45.79.0.0/21"))
+	})
+
+	t.Run("stores no metadata for rows without a location", func(t *testing.T) {
+		result, err := ParseGeofeed([]byte("168.144.52.0/22,None,None,None,None\n5.6.7.0/24\n5.6.7.0/24,DE\n"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"168.144.52.0/22", "5.6.7.0/24"}, result.IPv4)
+		assert.Nil(t, result.MetaFor("168.144.52.0/22"), "None is unknown")
+		assert.Nil(t, result.MetaFor("5.6.7.0/24"), "first row wins even without a location")
+		assert.Nil(t, result.Meta)
+	})
+
+	t.Run("first row wins for duplicate prefixes", func(t *testing.T) {
+		result, err := ParseGeofeed([]byte("1.2.3.0/24,US\n1.2.3.0/24,DE\n"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1.2.3.0/24"}, result.IPv4)
+		assert.Equal(t, "US", result.MetaFor("1.2.3.0/24").Country)
+	})
+
+	t.Run("empty and comment-only input", func(t *testing.T) {
+		for _, input := range []string{"", "# nothing here\n\n"} {
+			result, err := ParseGeofeed([]byte(input))
+			require.NoError(t, err)
+			assert.Nil(t, result.IPv4)
+			assert.Nil(t, result.IPv6)
+		}
+	})
+}
+
+func TestParseCommentedText(t *testing.T) {
 	tests := []struct {
 		name   string
 		input  string
@@ -418,7 +731,7 @@ func TestParseAlibaba(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := parseAlibaba([]byte(tc.input))
+			result, err := ParseCommentedText([]byte(tc.input))
 			require.NoError(t, err)
 			assert.Equal(t, tc.wantV4, result.IPv4)
 			assert.Equal(t, tc.wantV6, result.IPv6)
@@ -426,38 +739,121 @@ func TestParseAlibaba(t *testing.T) {
 	}
 }
 
+func TestASNSet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/as/24940/ipv4":
+			fmt.Fprint(w, "# AS24940\n5.9.0.0/16\n49.12.0.0/15\n")
+		case "/as/24940/ipv6":
+			fmt.Fprint(w, "# AS24940\n2a01:4f8::/29\n")
+		case "/as/213230/ipv4":
+			fmt.Fprint(w, "49.12.0.0/15\n5.161.0.0/16\n")
+		case "/as/213230/ipv6":
+			fmt.Fprint(w, "")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("merges AS numbers and records origin", func(t *testing.T) {
+		set := ASNSet{Name: "hetzner", ASNs: []int{24940, 213230}, URLTemplate: server.URL + "/as/{asn}/{family}"}
+		p := set.Provider()
+		assert.Equal(t, server.URL+"/as/24940/ipv4", p.URL)
+
+		dir := t.TempDir()
+		require.NoError(t, UpdateProvider(&p, dir))
+
+		loaded, err := Load("hetzner", dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"5.9.0.0/16", "5.161.0.0/16", "49.12.0.0/15"}, loaded.IPv4, "saved sorted by address")
+		assert.Equal(t, []string{"2a01:4f8::/29"}, loaded.IPv6)
+		assert.Equal(t, 24940, loaded.MetaFor("49.12.0.0/15").ASN)
+		assert.Equal(t, 213230, loaded.MetaFor("5.161.0.0/16").ASN)
+	})
+
+	t.Run("missing list fails", func(t *testing.T) {
+		set := ASNSet{Name: "gone", ASNs: []int{1}, URLTemplate: server.URL + "/as/{asn}/{family}"}
+		_, err := set.fetch()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "AS1 ipv4")
+	})
+
+	t.Run("no AS numbers", func(t *testing.T) {
+		_, err := ASNSet{Name: "empty"}.fetch()
+		assert.Error(t, err)
+	})
+
+	t.Run("default template", func(t *testing.T) {
+		assert.Equal(t,
+			"https://raw.githubusercontent.com/ipverse/asn-ip/master/as/45102/ipv6-aggregated.txt",
+			ASNSet{Name: "alibaba", ASNs: []int{45102}}.url(45102, "ipv6"))
+	})
+}
+
 func TestParseAnthropic(t *testing.T) {
 	tests := []struct {
-		name    string
-		input   string
-		wantV4  []string
-		wantV6  []string
-		wantErr bool
+		name        string
+		input       string
+		wantIn4     []string
+		wantIn6     []string
+		wantOut4    []string
+		wantOut6    []string
+		wantErr     bool
+		errContains string
 	}{
 		{
-			name: "extracts CIDRs from docs-like content",
-			input: `IP addresses
-Inbound IP addresses
-IPv4
-160.79.104.0/23
-IPv6
-2607:6bc0::/48
-Outbound IP addresses
-IPv4
-160.79.104.0/21`,
-			wantV4: []string{"160.79.104.0/23", "160.79.104.0/21"},
-			wantV6: []string{"2607:6bc0::/48"},
+			name: "separates inbound and outbound sections",
+			input: `<html><body>
+<h1>IP addresses</h1>
+<h2>Inbound IP addresses</h2>
+<p>These are the addresses our API is served from.</p>
+<h3>IPv4</h3><pre><code>160.79.104.0/23</code></pre>
+<h3>IPv6</h3><pre><code>2607:6bc0::/48</code></pre>
+<h2>Outbound IP addresses</h2>
+<h3>IPv4</h3><ul><li><code>160.79.104.0/21</code></li></ul>
+</body></html>`,
+			wantIn4:  []string{"160.79.104.0/23"},
+			wantIn6:  []string{"2607:6bc0::/48"},
+			wantOut4: []string{"160.79.104.0/21"},
 		},
 		{
 			name: "excludes phased-out IPs",
-			input: `Inbound IP addresses
-IPv4
-160.79.104.0/23
-Phased out IP addresses
-34.162.46.92/32
-34.162.102.82/32`,
-			wantV4: []string{"160.79.104.0/23"},
-			wantV6: nil,
+			input: `<html><body>
+<h2>Inbound IP addresses</h2><p><code>160.79.104.0/23</code></p>
+<h2>Outbound IP addresses</h2><p><code>160.79.104.0/21</code></p>
+<h3>Phased out IP addresses</h3>
+<ul><li><code>34.162.46.92/32</code></li><li><code>34.162.102.82/32</code></li></ul>
+</body></html>`,
+			wantIn4:  []string{"160.79.104.0/23"},
+			wantOut4: []string{"160.79.104.0/21"},
+		},
+		{
+			name: "unrelated heading ends a section",
+			input: `<html><body>
+<h2>Inbound IP addresses</h2><p><code>160.79.104.0/23</code></p>
+<h2>Outbound IP addresses</h2><p><code>160.79.104.0/21</code></p>
+<h2>Related pages</h2><p>Example: 52.0.0.0/8</p>
+</body></html>`,
+			wantIn4:  []string{"160.79.104.0/23"},
+			wantOut4: []string{"160.79.104.0/21"},
+		},
+		{
+			name: "missing outbound section fails",
+			input: `<html><body>
+<h2>Inbound IP addresses</h2><p><code>160.79.104.0/23</code></p>
+</body></html>`,
+			wantErr:     true,
+			errContains: "no outbound IP address section",
+		},
+		{
+			name: "empty inbound section fails",
+			input: `<html><body>
+<h2>Inbound IP addresses</h2><p>Coming soon.</p>
+<h2>Outbound IP addresses</h2><p><code>160.79.104.0/21</code></p>
+</body></html>`,
+			wantErr:     true,
+			errContains: "no CIDR ranges found in inbound section",
 		},
 		{
 			name:    "no CIDRs found",
@@ -473,18 +869,84 @@ Phased out IP addresses
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := parseAnthropic([]byte(tc.input))
+			result, err := splitAnthropic([]byte(tc.input))
 			if tc.wantErr {
-				assert.Error(t, err)
+				require.Error(t, err)
+				if tc.errContains != "" {
+					assert.Contains(t, err.Error(), tc.errContains)
+				}
 				return
 			}
 			require.NoError(t, err)
-			assert.Equal(t, tc.wantV4, result.IPv4)
-			assert.Equal(t, tc.wantV6, result.IPv6)
+			assert.Equal(t, tc.wantIn4, result["anthropic-inbound"].IPv4)
+			assert.Equal(t, tc.wantIn6, result["anthropic-inbound"].IPv6)
+			assert.Equal(t, tc.wantOut4, result["anthropic-outbound"].IPv4)
+			assert.Equal(t, tc.wantOut6, result["anthropic-outbound"].IPv6)
+
+			union := result["anthropic"]
+			assert.ElementsMatch(t, append(append([]string{}, tc.wantIn4...), tc.wantOut4...), union.IPv4)
 		})
 	}
 }
 
+func TestParseOracle(t *testing.T) {
+	t.Run("keeps region and tags per CIDR", func(t *testing.T) {
+		input := `{
+			"last_updated_timestamp": "2024-05-01T00:00:00.000000",
+			"regions": [
+				{
+					"region": "us-phoenix-1",
+					"cidrs": [
+						{"cidr": "129.146.0.0/21", "tags": ["OCI"]},
+						{"cidr": "134.70.8.0/21", "tags": ["OSN", "OBJECT_STORAGE"]}
+					]
+				},
+				{
+					"region": "eu-frankfurt-1",
+					"cidrs": [
+						{"cidr": "130.61.0.0/16", "tags": ["OCI"]},
+						{"cidr": "2603:c020::/30", "tags": ["OCI"]}
+					]
+				}
+			]
+		}`
+
+		result, err := parseOracle([]byte(input))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"129.146.0.0/21", "134.70.8.0/21", "130.61.0.0/16"}, result.IPv4)
+		assert.Equal(t, []string{"2603:c020::/30"}, result.IPv6)
+
+		meta := result.MetaFor("134.70.8.0/21")
+		require.NotNil(t, meta)
+		assert.Equal(t, "us-phoenix-1", meta.Region)
+		assert.Equal(t, []string{"OSN", "OBJECT_STORAGE"}, meta.Tags)
+		assert.Equal(t, "eu-frankfurt-1", result.MetaFor("2603:c020::/30").Region)
+	})
+
+	t.Run("first region wins for duplicate CIDRs", func(t *testing.T) {
+		input := `{"regions": [
+			{"region": "a", "cidrs": [{"cidr": "1.2.3.0/24", "tags": ["OCI"]}]},
+			{"region": "b", "cidrs": [{"cidr": "1.2.3.0/24", "tags": ["OSN"]}]}
+		]}`
+		result, err := parseOracle([]byte(input))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1.2.3.0/24"}, result.IPv4)
+		assert.Equal(t, "a", result.MetaFor("1.2.3.0/24").Region)
+	})
+
+	t.Run("empty regions", func(t *testing.T) {
+		result, err := parseOracle([]byte(`{"regions": []}`))
+		require.NoError(t, err)
+		assert.Nil(t, result.IPv4)
+		assert.Nil(t, result.Meta)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := parseOracle([]byte(`{broken`))
+		assert.Error(t, err)
+	})
+}
+
 func TestParseCommentedCIDRs(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -581,6 +1043,29 @@ func TestParseMicrosoftServiceTags(t *testing.T) {
 	}
 }
 
+func TestParseM365(t *testing.T) {
+	input := `[
+		{"id": 1, "serviceArea": "Exchange", "category": "Optimize", "required": true,
+		 "urls": ["outlook.office.com"], "ips": ["13.107.6.152/31", "2603:1006::/40"], "tcpPorts": "80,443"},
+		{"id": 9, "serviceArea": "Skype", "category": "Allow", "ips": ["52.112.0.0/14"]},
+		{"id": 46, "serviceArea": "Common", "category": "Default", "urls": ["*.msocdn.com"]},
+		{"id": 56, "serviceArea": "Common", "category": "Allow", "ips": ["13.107.6.152/31"]},
+		{"id": 57, "serviceArea": "Common", "ips": ["20.190.128.0/18"]}
+	]`
+
+	result, err := parseM365([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"13.107.6.152/31", "52.112.0.0/14", "20.190.128.0/18"}, result.IPv4)
+	assert.Equal(t, []string{"Common"}, result.MetaFor("20.190.128.0/18").Tags, "no category")
+	assert.Equal(t, []string{"2603:1006::/40"}, result.IPv6)
+	assert.Equal(t, []string{"Skype/allow"}, result.MetaFor("52.112.0.0/14").Tags)
+	assert.Equal(t, []string{"Exchange/optimize", "Common/allow"}, result.MetaFor("13.107.6.152/31").Tags,
+		"a prefix shared by endpoint sets keeps each service area with its category")
+
+	_, err = parseM365([]byte("{broken"))
+	assert.Error(t, err)
+}
+
 func TestDiscoverMicrosoftDownloadURL(t *testing.T) {
 	t.Run("finds ServiceTags link in HTML", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -734,6 +1219,59 @@ func TestSaveAndLoad(t *testing.T) {
 		assert.Equal(t, ipRange, loaded)
 	})
 
+	t.Run("round-trip preserves metadata of valid CIDRs", func(t *testing.T) {
+		dir := t.TempDir()
+		ipRange := &IPRange{
+			IPv4: []string{"129.146.0.0/21", "not-a-cidr"},
+			Meta: map[string]PrefixMeta{
+				"129.146.0.0/21": {Region: "us-phoenix-1", Tags: []string{"OCI"}},
+				"not-a-cidr":     {Region: "nowhere"},
+			},
+		}
+
+		require.NoError(t, Save("meta", ipRange, dir))
+
+		loaded, err := Load("meta", dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"129.146.0.0/21"}, loaded.IPv4)
+		assert.Equal(t, map[string]PrefixMeta{
+			"129.146.0.0/21": {Region: "us-phoenix-1", Tags: []string{"OCI"}},
+		}, loaded.Meta)
+	})
+
+	t.Run("normalises prefixes", func(t *testing.T) {
+		dir := t.TempDir()
+		ipRange := &IPRange{
+			IPv4: []string{"10.0.1.0/24", "10.0.0.7/24", "10.0.0.0/24", "10.0.5.0/24", "129.146.0.0/22", "129.146.4.0/22", "129.146.8.0/22"},
+			IPv6: []string{"2001:db8:8000::/33", "2001:db8::/33"},
+			Meta: map[string]PrefixMeta{
+				"129.146.0.0/22": {Region: "us-phoenix-1"},
+				"129.146.4.0/22": {Region: "us-phoenix-1"},
+				"129.146.8.0/22": {Region: "us-ashburn-1"},
+			},
+		}
+
+		require.NoError(t, Save("normalised", ipRange, dir))
+
+		loaded, err := Load("normalised", dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.0/23", "10.0.5.0/24", "129.146.0.0/21", "129.146.8.0/22"}, loaded.IPv4)
+		assert.Equal(t, []string{"2001:db8::/32"}, loaded.IPv6)
+		assert.Equal(t, map[string]PrefixMeta{
+			"129.146.0.0/21": {Region: "us-phoenix-1"},
+			"129.146.8.0/22": {Region: "us-ashburn-1"},
+		}, loaded.Meta, "only prefixes with the same metadata are merged")
+	})
+
+	t.Run("duplicate prefix keeps its metadata", func(t *testing.T) {
+		normalized := Normalize(&IPRange{
+			IPv4: []string{"192.0.2.0/24", "192.0.2.0/24 "},
+			Meta: map[string]PrefixMeta{"192.0.2.0/24 ": {Country: "NL"}},
+		})
+		assert.Equal(t, []string{"192.0.2.0/24"}, normalized.IPv4)
+		assert.Equal(t, "NL", normalized.MetaFor("192.0.2.0/24").Country)
+	})
+
 	t.Run("creates directory if missing", func(t *testing.T) {
 		dir := t.TempDir()
 		ipRange := &IPRange{IPv4: []string{"1.2.3.0/24"}}
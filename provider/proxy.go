@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// zscalerCENRURLs are the Cloud Enforcement Node Ranges of Zscaler's
+// production clouds.
+var zscalerCENRURLs = []string{
+	"https://config.zscaler.com/api/zscaler.net/cenr/json",
+	"https://config.zscaler.com/api/zscalerone.net/cenr/json",
+	"https://config.zscaler.com/api/zscalertwo.net/cenr/json",
+	"https://config.zscaler.com/api/zscalerthree.net/cenr/json",
+	"https://config.zscaler.com/api/zscloud.net/cenr/json",
+}
+
+func init() {
+	Register(Provider{
+		Name:        "apple-private-relay",
+		URL:         "https://mask-api.icloud.com/egress-ip-ranges.csv",
+		Parse:       ParseGeofeed,
+		DisplayName: "iCloud Private Relay",
+		Category:    CategoryProxy,
+		Color:       "hi-white",
+		Homepage:    "https://developer.apple.com/icloud/prepare-your-network-for-icloud-private-relay/",
+		License:     LicenseUnstated,
+	})
+	Register(Provider{
+		Name:        "cloudflare-warp",
+		URL:         "https://api.cloudflare.com/local-ip-ranges.csv",
+		Parse:       ParseGeofeed,
+		DisplayName: "Cloudflare WARP",
+		Category:    CategoryProxy,
+		Color:       "hi-red",
+		Homepage:    "https://developers.cloudflare.com/warp-client/",
+		License:     LicenseUnstated,
+	})
+	Register(Provider{
+		Name:        "zscaler",
+		URL:         zscalerCENRURLs[0],
+		Parse:       parseZscalerCENR,
+		Update:      updateZscaler,
+		DisplayName: "Zscaler",
+		Category:    CategoryProxy,
+		Color:       "hi-cyan",
+		Homepage:    "https://config.zscaler.com/",
+		License:     LicenseUnstated,
+	})
+}
+
+// parseZscalerCENR parses a Zscaler Cloud Enforcement Node Ranges document:
+//
+//	{"zscaler.net": {"continent : EMEA": {"city : Amsterdam II": [{"range": "..."}]}}}
+//
+// The continent and city of each range are kept as per-prefix metadata.
+func parseZscalerCENR(data []byte) (*IPRange, error) {
+	var doc map[string]map[string]map[string][]struct {
+		Range string `json:"range"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing Zscaler data: %w", err)
+	}
+
+	ipRange := &IPRange{}
+	seen := make(map[string]bool)
+	for _, cloud := range sortedKeys(doc) {
+		for _, continent := range sortedKeys(doc[cloud]) {
+			for _, city := range sortedKeys(doc[cloud][continent]) {
+				for _, node := range doc[cloud][continent][city] {
+					cidr := strings.TrimSpace(node.Range)
+					if cidr == "" || seen[cidr] {
+						continue
+					}
+					seen[cidr] = true
+
+					if strings.Contains(cidr, ":") {
+						ipRange.IPv6 = append(ipRange.IPv6, cidr)
+					} else {
+						ipRange.IPv4 = append(ipRange.IPv4, cidr)
+					}
+					ipRange.setMeta(cidr, PrefixMeta{
+						Region: zscalerLabel(continent),
+						City:   zscalerLabel(city),
+					})
+				}
+			}
+		}
+	}
+	return ipRange, nil
+}
+
+// zscalerLabel strips the "continent : " / "city : " prefix from a key.
+func zscalerLabel(key string) string {
+	if _, value, ok := strings.Cut(key, ":"); ok {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(key)
+}
+
+// sortedKeys returns the keys of m in order, so parsing is deterministic.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// updateZscaler merges the ranges of every Zscaler cloud into one provider.
+func updateZscaler(dataDir string) error {
+	ipRange, err := fetchAndMerge(zscalerCENRURLs, parseZscalerCENR)
+	if err != nil {
+		return fmt.Errorf("zscaler: %w", err)
+	}
+	return Save("zscaler", ipRange, dataDir)
+}
@@ -0,0 +1,148 @@
+package provider
+
+import "fmt"
+
+// SplitFunc parses a single response into IP ranges keyed by provider name.
+type SplitFunc func(data []byte) (map[string]*IPRange, error)
+
+// CollectFunc is an alternative fetch strategy for sources that need several
+// requests (e.g. one per Azure cloud). It returns ranges keyed by provider name.
+type CollectFunc func() (map[string]*IPRange, error)
+
+// Source is an upstream feed shared by several providers. It is fetched once
+// per update and fans out into one IP range per member provider, so a family
+// such as GitHub (/meta feeds github, githubactions, githubhooks, githubpages)
+// costs a single request. Providers join a source by setting Provider.Source.
+type Source struct {
+	Name    string
+	URL     string
+	Split   SplitFunc
+	Collect CollectFunc // if set, used instead of URL+Split
+}
+
+// Sources holds all registered shared sources.
+var Sources []Source
+
+// RegisterSource adds a shared source to the global list.
+func RegisterSource(s Source) {
+	Sources = append(Sources, s)
+}
+
+// SourceByName returns a shared source by name, or nil if not found.
+func SourceByName(name string) *Source {
+	for i := range Sources {
+		if Sources[i].Name == name {
+			return &Sources[i]
+		}
+	}
+	return nil
+}
+
+// Members returns the names of all registered providers fed by the source,
+// in registry order.
+func (s *Source) Members() []string {
+	var names []string
+	for _, p := range Registry {
+		if p.Source == s.Name {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// FetchSource downloads a shared source and splits it into per-provider ranges.
+func FetchSource(s *Source) (map[string]*IPRange, error) {
+	if s.Collect != nil {
+		return s.Collect()
+	}
+	if s.Split == nil {
+		return nil, fmt.Errorf("source %s has no parser", s.Name)
+	}
+
+	body, err := Fetch(s.URL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", s.Name, err)
+	}
+
+	return s.Split(body)
+}
+
+// UpdateSource fetches a shared source once and saves the ranges of every
+// member provider. It returns the names of the providers that were saved.
+// A member missing from the fetched data is an error, since it usually means
+// the upstream format changed.
+func UpdateSource(s *Source, dataDir string) ([]string, error) {
+	ranges, err := FetchSource(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var saved []string
+	for _, name := range s.Members() {
+		ipRange, ok := ranges[name]
+		if !ok {
+			return saved, fmt.Errorf("source %s returned no data for %s", s.Name, name)
+		}
+		if err := Save(name, ipRange, dataDir); err != nil {
+			return saved, fmt.Errorf("saving %s: %w", name, err)
+		}
+		saved = append(saved, name)
+	}
+
+	return saved, nil
+}
+
+// UpdateAll updates every registered provider, fetching each shared source only
+// once. report is called once per provider with the outcome of its update.
+// Local providers are skipped, and derived providers are recomputed last, from
+// the freshly updated data, each after the derived providers it uses.
+func UpdateAll(dataDir string, report func(name string, err error)) {
+	done := make(map[string]bool)
+
+	for i := range Registry {
+		p := &Registry[i]
+
+		if p.Load != nil || p.Expr != "" {
+			continue // local data is never overwritten; derived data comes last
+		}
+		if p.Source == "" {
+			_, err := updateProvider(p, dataDir)
+			report(p.Name, err)
+			continue
+		}
+		if done[p.Source] {
+			continue
+		}
+		done[p.Source] = true
+
+		s := SourceByName(p.Source)
+		if s == nil {
+			report(p.Name, fmt.Errorf("provider %s references unknown source %s", p.Name, p.Source))
+			continue
+		}
+
+		saved, err := UpdateSource(s, dataDir)
+		for _, name := range saved {
+			report(name, nil)
+		}
+		if err != nil {
+			for _, name := range s.Members()[len(saved):] {
+				report(name, err)
+			}
+		}
+	}
+
+	derived, err := derivedOrder(Registry)
+	if err != nil {
+		for i := range Registry {
+			if p := &Registry[i]; p.Expr != "" {
+				report(p.Name, err)
+			}
+		}
+		return
+	}
+	for _, p := range derived {
+		_, err := updateProvider(p, dataDir)
+		report(p.Name, err)
+	}
+}
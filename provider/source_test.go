@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withRegistry swaps the global registry and sources for the duration of a test.
+func withRegistry(t *testing.T, providers []Provider, sources []Source) {
+	t.Helper()
+	origRegistry, origSources := Registry, Sources
+	Registry, Sources = providers, sources
+	t.Cleanup(func() { Registry, Sources = origRegistry, origSources })
+}
+
+func TestUpdateSource(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		fmt.Fprint(w, `{"web": ["192.30.252.0/22"], "actions": ["4.148.0.0/15"], "hooks": ["140.82.112.0/20"], "pages": ["2606:50c0:8000::/48"]}`)
+	}))
+	defer server.Close()
+
+	withRegistry(t, []Provider{
+		{Name: "github", Source: "github"},
+		{Name: "githubactions", Source: "github"},
+		{Name: "githubhooks", Source: "github"},
+		{Name: "githubpages", Source: "github"},
+	}, []Source{
+		{Name: "github", URL: server.URL, Split: splitGitHubMeta},
+	})
+
+	t.Run("fetches once and saves every member", func(t *testing.T) {
+		dir := t.TempDir()
+		atomic.StoreInt32(&hits, 0)
+
+		saved, err := UpdateSource(SourceByName("github"), dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"github", "githubactions", "githubhooks", "githubpages"}, saved)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+		loaded, err := Load("githubpages", dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2606:50c0:8000::/48"}, loaded.IPv6)
+	})
+
+	t.Run("UpdateProvider refreshes the whole source", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, UpdateProvider(ByName("githubhooks"), dir))
+		assert.True(t, HasData("github", dir))
+		assert.True(t, HasData("githubhooks", dir))
+	})
+
+	t.Run("UpdateAll reports each member once", func(t *testing.T) {
+		dir := t.TempDir()
+		atomic.StoreInt32(&hits, 0)
+
+		var reported []string
+		UpdateAll(dir, func(name string, err error) {
+			assert.NoError(t, err)
+			reported = append(reported, name)
+		})
+		assert.Equal(t, []string{"github", "githubactions", "githubhooks", "githubpages"}, reported)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	})
+}
+
+func TestUpdateSource_Errors(t *testing.T) {
+	t.Run("missing member is an error", func(t *testing.T) {
+		withRegistry(t, []Provider{
+			{Name: "a", Source: "s"},
+			{Name: "b", Source: "s"},
+		}, []Source{{
+			Name: "s",
+			Collect: func() (map[string]*IPRange, error) {
+				return map[string]*IPRange{"a": {IPv4: []string{"1.1.1.0/24"}}}, nil
+			},
+		}})
+
+		var failed []string
+		UpdateAll(t.TempDir(), func(name string, err error) {
+			if err != nil {
+				failed = append(failed, name)
+			}
+		})
+		assert.Equal(t, []string{"b"}, failed)
+	})
+
+	t.Run("unknown source", func(t *testing.T) {
+		withRegistry(t, []Provider{{Name: "a", Source: "missing"}}, nil)
+		err := UpdateProvider(ByName("a"), t.TempDir())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown source")
+	})
+
+	t.Run("source without parser", func(t *testing.T) {
+		_, err := FetchSource(&Source{Name: "empty"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no parser")
+	})
+}
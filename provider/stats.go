@@ -0,0 +1,135 @@
+package provider
+
+import (
+	"math/big"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/BenjiTrapp/ip-to-cloudprovider/cidr"
+)
+
+// Stats describes the size and shape of a provider's dataset.
+type Stats struct {
+	Provider      string   `json:"provider"`
+	IPv4Prefixes  int      `json:"ipv4_prefixes"`
+	IPv6Prefixes  int      `json:"ipv6_prefixes"`
+	IPv4Addresses *big.Int `json:"ipv4_addresses"`
+	IPv6Addresses *big.Int `json:"ipv6_addresses"`
+
+	// RoutableShare is the fraction of publicly routable IPv4 space (see
+	// RoutableIPv4) the provider covers.
+	RoutableShare float64 `json:"routable_ipv4_share"`
+
+	// Largest and smallest prefix per family; ties go to the lowest address.
+	LargestIPv4  string `json:"largest_ipv4,omitempty"`
+	SmallestIPv4 string `json:"smallest_ipv4,omitempty"`
+	LargestIPv6  string `json:"largest_ipv6,omitempty"`
+	SmallestIPv6 string `json:"smallest_ipv6,omitempty"`
+
+	// Breakdowns by prefix metadata, largest first. Empty when the provider
+	// publishes no such details.
+	Regions  []Breakdown `json:"regions,omitempty"`
+	Services []Breakdown `json:"services,omitempty"`
+}
+
+// Breakdown is the part of a dataset carrying one region or service.
+type Breakdown struct {
+	Name          string   `json:"name"`
+	Prefixes      int      `json:"prefixes"`
+	IPv4Addresses *big.Int `json:"ipv4_addresses"`
+	IPv6Addresses *big.Int `json:"ipv6_addresses"`
+}
+
+// specialPurposeIPv4 lists the IANA special-purpose and reserved IPv4 blocks
+// that are not routed on the public internet, including multicast.
+var specialPurposeIPv4 = []string{
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8",
+	"169.254.0.0/16", "172.16.0.0/12", "192.0.0.0/24", "192.0.2.0/24",
+	"192.88.99.0/24", "192.168.0.0/16", "198.18.0.0/15", "198.51.100.0/24",
+	"203.0.113.0/24", "224.0.0.0/4", "240.0.0.0/4",
+}
+
+// RoutableIPv4 is the IPv4 space outside the special-purpose blocks.
+var RoutableIPv4 = func() []netip.Prefix {
+	special, _ := cidr.Parse(specialPurposeIPv4)
+	return cidr.Subtract([]netip.Prefix{netip.MustParsePrefix("0.0.0.0/0")}, special)
+}()
+
+// RangeStats summarises an IPRange in its normalised form (see Normalize), so
+// the numbers do not depend on how the data was stored.
+func RangeStats(providerName string, ipRange *IPRange) *Stats {
+	ipRange = Normalize(ipRange)
+	prefixes := ipRange.Prefixes()
+	s := &Stats{Provider: providerName}
+	s.IPv4Addresses, s.IPv6Addresses = cidr.Count(prefixes)
+
+	ipv4, ipv6 := splitFamilies(prefixes)
+	s.IPv4Prefixes, s.IPv6Prefixes = len(ipv4), len(ipv6)
+	s.LargestIPv4, s.SmallestIPv4 = prefixExtremes(ipv4)
+	s.LargestIPv6, s.SmallestIPv6 = prefixExtremes(ipv6)
+
+	routable, _ := cidr.Count(RoutableIPv4)
+	covered, _ := cidr.Count(cidr.Intersect(ipv4, RoutableIPv4))
+	s.RoutableShare, _ = new(big.Rat).SetFrac(covered, routable).Float64()
+
+	regions := make(map[string][]netip.Prefix)
+	services := make(map[string][]netip.Prefix)
+	for _, cidrs := range [][]string{ipRange.IPv4, ipRange.IPv6} {
+		for _, c := range cidrs {
+			meta := ipRange.MetaFor(c)
+			if meta == nil {
+				continue
+			}
+			prefix := netip.MustParsePrefix(c)
+			if meta.Region != "" {
+				regions[meta.Region] = append(regions[meta.Region], prefix)
+			}
+			for _, tag := range meta.Tags {
+				services[tag] = append(services[tag], prefix)
+			}
+		}
+	}
+	s.Regions = breakdowns(regions)
+	s.Services = breakdowns(services)
+	return s
+}
+
+// prefixExtremes returns the shortest and longest prefix of a list.
+func prefixExtremes(prefixes []netip.Prefix) (largest, smallest string) {
+	if len(prefixes) == 0 {
+		return "", ""
+	}
+	sorted := slices.Clone(prefixes)
+	slices.SortFunc(sorted, func(a, b netip.Prefix) int {
+		if a.Bits() != b.Bits() {
+			return a.Bits() - b.Bits()
+		}
+		return a.Addr().Compare(b.Addr())
+	})
+	shortest := sorted[0]
+	i := slices.IndexFunc(sorted, func(p netip.Prefix) bool { return p.Bits() == sorted[len(sorted)-1].Bits() })
+	return shortest.String(), sorted[i].String()
+}
+
+// breakdowns turns grouped prefixes into Breakdowns, largest first.
+func breakdowns(groups map[string][]netip.Prefix) []Breakdown {
+	out := make([]Breakdown, 0, len(groups))
+	for name, prefixes := range groups {
+		v4, v6 := cidr.Count(prefixes)
+		out = append(out, Breakdown{Name: name, Prefixes: len(prefixes), IPv4Addresses: v4, IPv6Addresses: v6})
+	}
+	slices.SortFunc(out, func(a, b Breakdown) int {
+		if c := b.IPv4Addresses.Cmp(a.IPv4Addresses); c != 0 {
+			return c
+		}
+		if c := b.IPv6Addresses.Cmp(a.IPv6Addresses); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BenjiTrapp/ip-to-cloudprovider/cidr"
+)
+
+func TestRoutableIPv4(t *testing.T) {
+	ipv4, ipv6 := cidr.Count(RoutableIPv4)
+	assert.Equal(t, int64(3702258432), ipv4.Int64())
+	assert.Zero(t, ipv6.Sign())
+}
+
+func TestRangeStats(t *testing.T) {
+	s := RangeStats("oracle", &IPRange{
+		IPv4: []string{"129.146.0.0/21", "129.146.8.0/21", "130.61.0.0/16", "192.0.2.10/32"},
+		IPv6: []string{"2603:c020::/32", "2603:c020::/40"},
+		Meta: map[string]PrefixMeta{
+			"129.146.0.0/21": {Region: "us-phoenix-1", Tags: []string{"OCI"}},
+			"129.146.8.0/21": {Region: "us-phoenix-1", Tags: []string{"OCI"}},
+			"130.61.0.0/16":  {Region: "eu-frankfurt-1", Tags: []string{"OCI", "OSN"}},
+		},
+	})
+
+	assert.Equal(t, "oracle", s.Provider)
+	assert.Equal(t, 3, s.IPv4Prefixes, "same-region neighbours are merged")
+	assert.Equal(t, 1, s.IPv6Prefixes, "covered prefixes are dropped")
+	assert.Equal(t, int64(65536+4096+1), s.IPv4Addresses.Int64())
+	assert.Equal(t, uint(97), uint(s.IPv6Addresses.BitLen()))
+	assert.InDelta(t, float64(65536+4096)/3702258432, s.RoutableShare, 1e-12, "documentation space is not routable")
+
+	assert.Equal(t, "130.61.0.0/16", s.LargestIPv4)
+	assert.Equal(t, "192.0.2.10/32", s.SmallestIPv4)
+	assert.Equal(t, "2603:c020::/32", s.LargestIPv6)
+	assert.Equal(t, "2603:c020::/32", s.SmallestIPv6)
+
+	require.Len(t, s.Regions, 2)
+	assert.Equal(t, "eu-frankfurt-1", s.Regions[0].Name)
+	assert.Equal(t, "us-phoenix-1", s.Regions[1].Name)
+	assert.Equal(t, 1, s.Regions[1].Prefixes)
+	assert.Equal(t, int64(4096), s.Regions[1].IPv4Addresses.Int64())
+
+	require.Len(t, s.Services, 2)
+	assert.Equal(t, "OCI", s.Services[0].Name)
+	assert.Equal(t, int64(65536+4096), s.Services[0].IPv4Addresses.Int64())
+	assert.Equal(t, "OSN", s.Services[1].Name)
+
+	empty := RangeStats("empty", &IPRange{})
+	assert.Zero(t, empty.IPv4Addresses.Sign())
+	assert.Zero(t, empty.RoutableShare)
+	assert.Empty(t, empty.LargestIPv4)
+	assert.Nil(t, empty.Regions)
+}
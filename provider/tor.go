@@ -0,0 +1,18 @@
+package provider
+
+import "time"
+
+func init() {
+	// The bulk exit list changes within hours, so it goes stale quickly.
+	Register(Provider{
+		Name:            "tor",
+		URL:             "https://check.torproject.org/torbulkexitlist",
+		Parse:           ParseIPList,
+		DisplayName:     "Tor exit",
+		Category:        CategoryProxy,
+		Color:           "magenta",
+		Homepage:        "https://metrics.torproject.org/",
+		License:         "CC0-1.0",
+		RefreshInterval: time.Hour,
+	})
+}